@@ -0,0 +1,172 @@
+package accesslog
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"arvanch/config"
+	"arvanch/i18n"
+	"arvanch/log/access"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+const (
+	flagFile      = "file"
+	flagKeysFile  = "keys-file"
+	flagRecipient = "recipient"
+	flagUUID      = "uuid"
+	flagFrom      = "from"
+	flagTo        = "to"
+
+	// keysEnv holds the keys in JSON when no keys file is given.
+	keysEnv = "ACCESS_LOG_KEYS"
+
+	stdin = "-"
+)
+
+var errNoKeys = errors.New("access log keys are required, set --" + flagKeysFile + " or " + keysEnv)
+
+type options struct {
+	file      string
+	keysFile  string
+	recipient string
+	uuid      string
+	from      string
+	to        string
+}
+
+func main(cmd *cobra.Command, opts options, cfg config.Config) error {
+	keys, err := loadKeys(opts.keysFile)
+	if err != nil {
+		return err
+	}
+
+	reader, err := access.NewReader(keys)
+	if err != nil {
+		return err
+	}
+
+	query, err := parseQuery(opts, cfg.I18N.Region)
+	if err != nil {
+		return err
+	}
+
+	var in io.Reader = os.Stdin
+
+	if opts.file != stdin {
+		f, err := os.Open(opts.file)
+		if err != nil {
+			return err
+		}
+
+		defer func() {
+			if err := f.Close(); err != nil {
+				logrus.Error(err.Error())
+			}
+		}()
+
+		in = f
+	}
+
+	n, err := reader.Read(in, cmd.OutOrStdout(), query)
+	if err != nil {
+		return fmt.Errorf("reading access log failed after %d entries: %w", n, err)
+	}
+
+	logrus.Infof("%d entries found", n)
+
+	return nil
+}
+
+func loadKeys(keysFile string) (access.Keys, error) {
+	if keysFile != "" {
+		return access.ReadKeysFile(keysFile)
+	}
+
+	if keys, ok := os.LookupEnv(keysEnv); ok {
+		return access.ParseKeys([]byte(keys))
+	}
+
+	return access.Keys{}, errNoKeys
+}
+
+func parseQuery(opts options, region string) (access.Query, error) {
+	var (
+		query access.Query
+		err   error
+	)
+
+	if opts.recipient != "" {
+		r, err := i18n.ToRegion(region)
+		if err != nil {
+			return query, err
+		}
+
+		recipient, err := i18n.Normalize(opts.recipient, r)
+		if err != nil {
+			return query, fmt.Errorf("invalid --%s: %w", flagRecipient, err)
+		}
+
+		query.Recipient = recipient.E164
+	}
+
+	if opts.uuid != "" {
+		if _, err := uuid.Parse(opts.uuid); err != nil {
+			return query, fmt.Errorf("invalid --%s: %w", flagUUID, err)
+		}
+
+		query.UUID = opts.uuid
+	}
+
+	if opts.from != "" {
+		if query.From, err = time.Parse(time.RFC3339, opts.from); err != nil {
+			return query, fmt.Errorf("invalid --%s: %w", flagFrom, err)
+		}
+	}
+
+	if opts.to != "" {
+		if query.To, err = time.Parse(time.RFC3339, opts.to); err != nil {
+			return query, fmt.Errorf("invalid --%s: %w", flagTo, err)
+		}
+	}
+
+	return query, nil
+}
+
+// Register access-log command.
+func Register(root *cobra.Command, cfg config.Config) {
+	var opts options
+
+	cmd := &cobra.Command{
+		Use:   "access-log",
+		Short: "Reads the sms access log with secured fields decrypted",
+		Long: "Streams an access log file, plain or gzip compressed, and writes the entries matching the filters\n" +
+			"as JSON lines with payload and recipient decrypted.\n" +
+			"The keys are not read from the configuration, they should be given in a file only readable by its owner\n" +
+			"with --" + flagKeysFile + " or in " + keysEnv + ", as JSON: " +
+			`{"encryption-keys": {"active": "1", "keys": {"1": "..."}}, "hmac-keys": {...}}`,
+
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return main(cmd, opts, cfg)
+		},
+	}
+
+	cmd.Flags().StringVar(&opts.file, flagFile, "", "access log file to read, - reads from stdin")
+	cmd.Flags().StringVar(&opts.keysFile, flagKeysFile, "", "file with the access log keys")
+	cmd.Flags().StringVar(&opts.recipient, flagRecipient, "", "only entries sent to this phone number")
+	cmd.Flags().StringVar(&opts.uuid, flagUUID, "", "only the entry of this message uuid")
+	cmd.Flags().StringVar(&opts.from, flagFrom, "", "only entries logged at or after this RFC3339 time")
+	cmd.Flags().StringVar(&opts.to, flagTo, "", "only entries logged before this RFC3339 time")
+
+	if err := cmd.MarkFlagRequired(flagFile); err != nil {
+		logrus.Fatal(err.Error())
+	}
+
+	root.AddCommand(cmd)
+}
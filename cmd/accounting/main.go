@@ -13,7 +13,13 @@ import (
 	"arvanch/db"
 	"arvanch/handler"
 	"arvanch/i18n"
+	"arvanch/log"
 	"arvanch/log/access"
+	"arvanch/pkg/health"
+	"arvanch/pkg/metrics"
+	"arvanch/pkg/requestid"
+	"arvanch/pkg/security"
+	"arvanch/pkg/tracing"
 	"arvanch/repository"
 	"arvanch/request"
 
@@ -47,7 +53,15 @@ func Register(root *cobra.Command, cfg config.Config) {
 
 // nolint:funlen
 func main(port int, cfg config.Config) {
-	database := db.WithRetry(db.Create, cfg.Postgres)
+	connectCtx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+
+	database, err := db.WithRetry(connectCtx, db.Create, cfg.Postgres)
+
+	stop()
+
+	if err != nil {
+		logrus.Fatalf("accounting : failed to connect to postgres: %s", err.Error())
+	}
 
 	defer func() {
 		if err := database.Close(); err != nil {
@@ -55,11 +69,45 @@ func main(port int, cfg config.Config) {
 		}
 	}()
 
+	if err := db.RegisterMetrics(database, cfg.Postgres.DBName); err != nil {
+		logrus.Errorf("accounting : failed to register database metrics: %s", err.Error())
+	}
+
+	checks := []health.Check{{Name: "postgres", Func: db.Ping(database)}}
+
+	if cfg.Health.MigrationsPath != "" {
+		migrations, err := db.Migrations(database, cfg.Health.MigrationsPath)
+		if err != nil {
+			logrus.Fatalf("accounting : failed to setup migrations check: %s", err.Error())
+		}
+
+		checks = append(checks, health.Check{Name: "migrations", Func: migrations})
+	}
+
+	if cfg.NATS.PublishEnabled {
+		checks = append(checks, health.Check{Name: "nats", Func: health.TCP(cfg.NATS.URL)})
+	}
+
+	readiness := health.NewReadiness(cfg.Health.Timeout, checks...)
+
+	metricsServer := metrics.StartServer(cfg.Monitoring.Prometheus)
+
+	shutdownTracing, err := tracing.Setup(cfg.Tracing, "accounting")
+	if err != nil {
+		logrus.Fatalf("accounting : failed to setup tracing: %s", err.Error())
+	}
+
 	e := echo.New()
+	e.HTTPErrorHandler = handler.HTTPErrorHandler
 
 	e.Use(middleware.CORS())
+	e.Use(tracing.Middleware("accounting"))
+	e.Use(requestid.Middleware())
+	e.Use(log.Middleware())
+	e.Use(metrics.HTTPMetrics("accounting"))
 
 	e.GET("/healthz", func(c echo.Context) error { return c.NoContent(http.StatusNoContent) })
+	e.GET("/readyz", readiness.Handler)
 
 	api := e.Group("/api")
 
@@ -75,25 +123,77 @@ func main(port int, cfg config.Config) {
 		logrus.Fatalf("accounting : invalid region: %s", err)
 	}
 
-	accessLogger, err := access.NewAccessLogger(cfg.CustomAccessLogger)
+	accessLogger, err := access.NewAccessLogger(cfg.CustomAccessLogger, cfg.MaskRecipient)
 	if err != nil {
 		logrus.Fatalf("accounting : failed to init access logger: %s", err.Error())
 	}
 
-	msgRepo := repository.NewMessageRepo(database)
+	resolver := db.NewResolver(database, db.ConnectReplicas(cfg.Postgres), cfg.Postgres.MaxReplicaLag)
+
+	defer func() {
+		if err := resolver.Close(); err != nil {
+			logrus.Error(err.Error())
+		}
+	}()
+
+	cipher, err := repository.NewMessageCipher(cfg.Encryption)
+	if err != nil {
+		logrus.Fatalf("accounting : failed to create message cipher: %s", err.Error())
+	}
+
+	msgRepo := repository.NewMessageRepo(resolver, cipher)
+	suppressionRepo := repository.NewSuppressionRepo(database)
+	recipientHMAC, err := security.NewHMACKeyring(cfg.Suppression.HMACKeys)
+	if err != nil {
+		logrus.Fatalf("accounting : invalid suppression hmac keys: %s", err.Error())
+	}
 
 	smsHandler := handler.NewSMSHandler(
 		msgRepo,
+		suppressionRepo,
+		region,
+		reqValidator,
+		recipientHMAC,
+		cfg.I18N.WhiteList.SMS,
+		cfg.MaskRecipient,
+	)
+
+	suppressionHandler := handler.NewSuppressionHandler(
+		msgRepo,
+		suppressionRepo,
 		region,
-		accessLogger,
+		recipientHMAC,
+		cfg.Suppression.Keywords,
 		reqValidator,
 	)
 
-	api.POST("/account/register", smsHandler.CreateAccount)
-	api.GET("/account/profile", smsHandler.GetProfile)
+	searchHandler := handler.NewSearchHandler(repository.NewSearchRepo(resolver, cipher), region, reqValidator)
+
+	api.POST("/account/register", smsHandler.CreateAccount, access.Middleware(accessLogger, access.EventAccountCreate))
+	api.GET("/account/profile", smsHandler.GetProfile, access.Middleware(accessLogger, access.EventProfileRead))
+
+	api.POST("/account/charge", smsHandler.ChargeAccount, access.Middleware(accessLogger, access.EventAccountCharge))
+	api.GET("/account/messages", smsHandler.GetUserMessages, access.Middleware(accessLogger, access.EventMessagesList))
 
-	api.POST("/account/charge", smsHandler.ChargeAccount)
-	api.GET("/account/messages", smsHandler.GetUserMessages)
+	api.POST("/account/suppressions", suppressionHandler.AddAccount,
+		access.Middleware(accessLogger, access.EventSuppressionAdd))
+	api.DELETE("/account/suppressions", suppressionHandler.RemoveAccount,
+		access.Middleware(accessLogger, access.EventSuppressionRemove))
+	api.GET("/account/suppressions", suppressionHandler.ListAccount,
+		access.Middleware(accessLogger, access.EventSuppressionList))
+
+	admin := api.Group("/admin", handler.TokenAuth(cfg.Token))
+
+	admin.POST("/suppressions", suppressionHandler.AddGlobal, access.Middleware(accessLogger, access.EventSuppressionAdd))
+	admin.DELETE("/suppressions", suppressionHandler.RemoveGlobal,
+		access.Middleware(accessLogger, access.EventSuppressionRemove))
+	admin.GET("/suppressions", suppressionHandler.ListGlobal, access.Middleware(accessLogger, access.EventSuppressionList))
+
+	admin.PUT("/accounts/:id/sandbox", smsHandler.SetSandbox, access.Middleware(accessLogger, access.EventSandboxSet))
+	admin.PUT("/accounts/:id/whitelist", smsHandler.SetWhitelist, access.Middleware(accessLogger, access.EventWhitelistSet))
+	admin.GET("/accounts/:id/whitelist", smsHandler.GetWhitelist, access.Middleware(accessLogger, access.EventWhitelistRead))
+
+	admin.GET("/messages/search", searchHandler.SearchMessages, access.Middleware(accessLogger, access.EventMessageSearch))
 
 	sig := make(chan os.Signal, 1)
 	signal.Notify(sig, os.Interrupt, syscall.SIGTERM)
@@ -107,10 +207,24 @@ func main(port int, cfg config.Config) {
 	s := <-sig
 	logrus.Infof("signal %s received\n", s)
 
+	// report not-ready and keep serving in-flight and new requests until load balancers notice.
+	readiness.Drain()
+	<-time.After(cfg.Health.DrainPeriod)
+
 	ctx, cancel := context.WithTimeout(context.Background(), exitTimeout)
 	defer cancel()
 
 	if err := e.Shutdown(ctx); err != nil {
 		logrus.Error(err.Error())
 	}
+
+	if metricsServer != nil {
+		if err := metricsServer.Shutdown(ctx); err != nil {
+			logrus.Error(err.Error())
+		}
+	}
+
+	if err := shutdownTracing(ctx); err != nil {
+		logrus.Error(err.Error())
+	}
 }
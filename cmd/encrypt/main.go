@@ -0,0 +1,92 @@
+package encrypt
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"arvanch/config"
+	"arvanch/db"
+	"arvanch/repository"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+const flagBatchSize = "batch-size"
+
+func main(ctx context.Context, cmd *cobra.Command, batchSize int, cfg config.Config) error {
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	database, err := db.WithRetry(ctx, db.Create, cfg.Postgres)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if err := database.Close(); err != nil {
+			logrus.Error(err.Error())
+		}
+	}()
+
+	cipher, err := repository.NewMessageCipher(cfg.Encryption)
+	if err != nil {
+		return err
+	}
+
+	msgRepo := repository.NewMessageRepo(db.NewResolver(database, nil, 0), cipher)
+
+	total := 0
+
+	// each batch is committed on its own, so an interrupted run can be resumed by running the command again.
+	for ctx.Err() == nil {
+		n, err := msgRepo.EncryptBatch(ctx, batchSize)
+		if err != nil {
+			return fmt.Errorf("encrypting messages failed after %d messages: %w", total, err)
+		}
+
+		if n == 0 {
+			cmd.Printf("%d messages encrypted\n", total)
+
+			return nil
+		}
+
+		total += n
+
+		logrus.Infof("%d messages encrypted so far", total)
+	}
+
+	return fmt.Errorf("encrypting messages interrupted after %d messages: %w", total, ctx.Err())
+}
+
+// Register encrypt-messages command.
+func Register(root *cobra.Command, cfg config.Config) {
+	cmd := &cobra.Command{
+		Use:   "encrypt-messages",
+		Short: "Encrypts the payload and recipient of messages stored in plaintext or with a rotated key",
+		Long: "Encrypts the payload and recipient of messages stored before encryption was enabled,\n" +
+			"or re-encrypts them with the active key after a key rotation.\n" +
+			"encryption.enabled should be set, and servers should be running with it before this command runs.\n" +
+			"Rotated keys should be kept in encryption.keys until this command finishes.",
+
+		RunE: func(cmd *cobra.Command, args []string) error {
+			batchSize, err := cmd.Flags().GetInt(flagBatchSize)
+			if err != nil {
+				return err
+			}
+
+			if batchSize <= 0 {
+				return fmt.Errorf("%s flag should be positive", flagBatchSize)
+			}
+
+			return main(cmd.Context(), cmd, batchSize, cfg)
+		},
+	}
+
+	cmd.Flags().Int(flagBatchSize, cfg.Encryption.BatchSize, "number of messages encrypted in each transaction")
+
+	root.AddCommand(cmd)
+}
@@ -14,7 +14,14 @@ import (
 	"arvanch/db"
 	"arvanch/handler"
 	"arvanch/i18n"
+	"arvanch/log"
 	"arvanch/log/access"
+	"arvanch/log/dpn"
+	"arvanch/pkg/health"
+	"arvanch/pkg/metrics"
+	"arvanch/pkg/requestid"
+	"arvanch/pkg/security"
+	"arvanch/pkg/tracing"
 	"arvanch/repository"
 	"arvanch/request"
 
@@ -53,7 +60,15 @@ func main(port int, cfg config.Config) {
 		logrus.Fatalf("messanger : invalid region: %s", err)
 	}
 
-	database := db.WithRetry(db.Create, cfg.Postgres)
+	connectCtx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+
+	database, err := db.WithRetry(connectCtx, db.Create, cfg.Postgres)
+
+	stop()
+
+	if err != nil {
+		logrus.Fatalf("messanger : failed to connect to postgres: %s", err.Error())
+	}
 
 	defer func() {
 		if err := database.Close(); err != nil {
@@ -61,15 +76,49 @@ func main(port int, cfg config.Config) {
 		}
 	}()
 
+	if err := db.RegisterMetrics(database, cfg.Postgres.DBName); err != nil {
+		logrus.Errorf("messanger : failed to register database metrics: %s", err.Error())
+	}
+
+	checks := []health.Check{{Name: "postgres", Func: db.Ping(database)}}
+
+	if cfg.Health.MigrationsPath != "" {
+		migrations, err := db.Migrations(database, cfg.Health.MigrationsPath)
+		if err != nil {
+			logrus.Fatalf("messanger : failed to setup migrations check: %s", err.Error())
+		}
+
+		checks = append(checks, health.Check{Name: "migrations", Func: migrations})
+	}
+
+	if cfg.NATS.PublishEnabled {
+		checks = append(checks, health.Check{Name: "nats", Func: health.TCP(cfg.NATS.URL)})
+	}
+
+	readiness := health.NewReadiness(cfg.Health.Timeout, checks...)
+
+	metricsServer := metrics.StartServer(cfg.Monitoring.Prometheus)
+
+	shutdownTracing, err := tracing.Setup(cfg.Tracing, "messanger")
+	if err != nil {
+		logrus.Fatalf("messanger : failed to setup tracing: %s", err.Error())
+	}
+
 	e := echo.New()
+	e.HTTPErrorHandler = handler.HTTPErrorHandler
 
 	e.Use(middleware.CORS())
+	e.Use(tracing.Middleware("messanger"))
+	e.Use(requestid.Middleware())
+	e.Use(log.Middleware())
+	e.Use(metrics.HTTPMetrics("messanger"))
 
 	e.GET("/healthz", func(c echo.Context) error { return c.NoContent(http.StatusNoContent) })
+	e.GET("/readyz", readiness.Handler)
 
 	api := e.Group("/api")
 
-	accessLogger, err := access.NewAccessLogger(cfg.CustomAccessLogger)
+	accessLogger, err := access.NewAccessLogger(cfg.CustomAccessLogger, cfg.MaskRecipient)
 	if err != nil {
 		logrus.Fatalf("messanger : failed to init access logger: %s", err.Error())
 	}
@@ -79,16 +128,57 @@ func main(port int, cfg config.Config) {
 		logrus.Fatalf("messanger : failed to create validator : %s", err.Error())
 	}
 
-	msgRepo := repository.NewMessageRepo(database)
+	dpnLogger, err := dpn.NewDPNLogger(cfg.DPNLogger, cfg.MaskRecipient)
+	if err != nil {
+		logrus.Fatalf("messanger : failed to init dpn logger: %s", err.Error())
+	}
+
+	resolver := db.NewResolver(database, db.ConnectReplicas(cfg.Postgres), cfg.Postgres.MaxReplicaLag)
+
+	defer func() {
+		if err := resolver.Close(); err != nil {
+			logrus.Error(err.Error())
+		}
+	}()
+
+	cipher, err := repository.NewMessageCipher(cfg.Encryption)
+	if err != nil {
+		logrus.Fatalf("messanger : failed to create message cipher: %s", err.Error())
+	}
+
+	msgRepo := repository.NewMessageRepo(resolver, cipher)
+	suppressionRepo := repository.NewSuppressionRepo(database)
+	recipientHMAC, err := security.NewHMACKeyring(cfg.Suppression.HMACKeys)
+	if err != nil {
+		logrus.Fatalf("messanger : invalid suppression hmac keys: %s", err.Error())
+	}
 
 	smsHandler := handler.NewSMSHandler(
 		msgRepo,
+		suppressionRepo,
 		region,
-		accessLogger,
 		reqValidator,
+		recipientHMAC,
+		cfg.I18N.WhiteList.SMS,
+		cfg.MaskRecipient,
 	)
 
-	api.POST("/sms/phone", smsHandler.Sms)
+	suppressionHandler := handler.NewSuppressionHandler(
+		msgRepo,
+		suppressionRepo,
+		region,
+		recipientHMAC,
+		cfg.Suppression.Keywords,
+		reqValidator,
+	)
+
+	dlrHandler := handler.NewDLRHandler(msgRepo, dpnLogger, reqValidator)
+
+	api.POST("/sms/phone", smsHandler.Sms, access.Middleware(accessLogger, access.EventSMSSend))
+	api.POST("/sms/inbound", suppressionHandler.Inbound,
+		handler.TokenAuth(cfg.ReporterToken), access.Middleware(accessLogger, access.EventInboundReceived))
+	api.POST("/sms/dlr", dlrHandler.SMS,
+		handler.TokenAuth(cfg.ReporterToken), access.Middleware(accessLogger, access.EventDLRReceived))
 
 	sig := make(chan os.Signal, 1)
 	signal.Notify(sig, os.Interrupt, syscall.SIGTERM)
@@ -102,10 +192,24 @@ func main(port int, cfg config.Config) {
 	s := <-sig
 	logrus.Infof("signal %s received\n", s)
 
+	// report not-ready and keep serving in-flight and new requests until load balancers notice.
+	readiness.Drain()
+	<-time.After(cfg.Health.DrainPeriod)
+
 	ctx, cancel := context.WithTimeout(context.Background(), exitTimeout)
 	defer cancel()
 
 	if err := e.Shutdown(ctx); err != nil {
 		logrus.Error(err.Error())
 	}
+
+	if metricsServer != nil {
+		if err := metricsServer.Shutdown(ctx); err != nil {
+			logrus.Error(err.Error())
+		}
+	}
+
+	if err := shutdownTracing(ctx); err != nil {
+		logrus.Error(err.Error())
+	}
 }
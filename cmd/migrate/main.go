@@ -1,7 +1,11 @@
 package migrate
 
 import (
+	"context"
 	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
 
 	"github.com/sirupsen/logrus"
 
@@ -19,8 +23,14 @@ const (
 	flagPath = "path"
 )
 
-func main(path string, cfg config.Config) error {
-	database := db.WithRetry(db.Create, cfg.Postgres)
+func main(ctx context.Context, path string, cfg config.Config) error {
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	database, err := db.WithRetry(ctx, db.Create, cfg.Postgres)
+	if err != nil {
+		return err
+	}
 
 	defer func() {
 		if err := database.Close(); err != nil {
@@ -75,7 +85,7 @@ func Register(root *cobra.Command, cfg config.Config) {
 				return err
 			}
 
-			if err := main(path, cfg); err != nil {
+			if err := main(cmd.Context(), path, cfg); err != nil {
 				return err
 			}
 
@@ -3,10 +3,13 @@ package cmd
 import (
 	"os"
 
+	"arvanch/cmd/accesslog"
 	"arvanch/cmd/accounting"
+	"arvanch/cmd/encrypt"
 	"arvanch/cmd/messanger"
 	"arvanch/cmd/migrate"
 	"arvanch/config"
+	"arvanch/i18n"
 	"arvanch/log"
 
 	"github.com/sirupsen/logrus"
@@ -23,13 +26,21 @@ func Execute() {
 		Short: "arvanch sends sms and email messages to users",
 	}
 
-	log.SetupLogger(cfg.Logger)
+	if err := log.SetupLogger(cfg.Logger, cfg.MaskRecipient); err != nil {
+		logrus.Fatalf("failed to setup logger: %s", err)
+	}
 
 	logrus.Debugf("config loaded: %+v", cfg)
 
+	if err := i18n.Load(cfg.I18N.Regions); err != nil {
+		logrus.Fatalf("failed to load regions: %s", err)
+	}
+
 	messanger.Register(cmd, cfg)
 	accounting.Register(cmd, cfg)
 	migrate.Register(cmd, cfg)
+	encrypt.Register(cmd, cfg)
+	accesslog.Register(cmd, cfg)
 
 	if err := cmd.Execute(); err != nil {
 		logrus.Error(err.Error())
@@ -0,0 +1,49 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestKeyringWithLegacy(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name     string
+		keyring  Keyring
+		legacy   string
+		expected Keyring
+	}{
+		{
+			name:     "no legacy key",
+			keyring:  Keyring{Active: "1", Keys: map[string]string{"1": "new"}},
+			expected: Keyring{Active: "1", Keys: map[string]string{"1": "new"}},
+		},
+		{
+			name:     "legacy key only",
+			legacy:   "old",
+			expected: Keyring{Active: LegacyKeyID, Keys: map[string]string{LegacyKeyID: "old"}},
+		},
+		{
+			name:     "legacy key kept for reads",
+			keyring:  Keyring{Active: "1", Keys: map[string]string{"1": "new"}},
+			legacy:   "old",
+			expected: Keyring{Active: "1", Keys: map[string]string{"1": "new", LegacyKeyID: "old"}},
+		},
+		{
+			name:     "legacy key already in keyring",
+			keyring:  Keyring{Active: "1", Keys: map[string]string{"1": "old"}},
+			legacy:   "old",
+			expected: Keyring{Active: "1", Keys: map[string]string{"1": "old"}},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			require.Equal(t, tc.expected, tc.keyring.WithLegacy(tc.legacy))
+		})
+	}
+}
@@ -20,20 +20,82 @@ func Default() Config {
 		I18N: I18N{
 			Region:    "turkey",
 			WhiteList: WhiteList{SMS: []string{"arvan"}},
+			Regions: map[string]Region{
+				// https://en.wikipedia.org/wiki/Telephone_numbers_in_Iran
+				"arvan": {
+					Aliases:        []string{"iran"},
+					Country:        "IR",
+					CallingCode:    "98",
+					NationalPrefix: "0",
+					NumberPatterns: map[string]string{
+						"mobile":   `9[0-9]{9}`,
+						"landline": `[1-8][0-9]{9}`,
+					},
+					RecipientPattern: `(0|\+98)[0-9]{10}`,
+					Locales:          []string{"ar", "en", "ku"},
+					Timezone:         "Asia/Tehran",
+				},
+				// https://en.wikipedia.org/wiki/Telephone_numbers_in_Turkey
+				"turkey": {
+					Country:        "TR",
+					CallingCode:    "90",
+					NationalPrefix: "0",
+					NumberPatterns: map[string]string{
+						"mobile":   `5[0-9]{9}`,
+						"landline": `[2-4][0-9]{9}`,
+					},
+					RecipientPattern: `\+90[0-9]{10}`,
+					Locales:          []string{"fa", "en"},
+					Timezone:         "Europe/Istanbul",
+				},
+				// https://en.wikipedia.org/wiki/Telephone_numbers_in_Iraq
+				"iraq": {
+					Country:        "IQ",
+					CallingCode:    "964",
+					NationalPrefix: "0",
+					NumberPatterns: map[string]string{
+						"mobile":   `7[0-9]{9}`,
+						"landline": `1[0-9]{7}|[2-6][0-9]{8}`,
+					},
+					Locales:  []string{"ar", "ku", "en"},
+					Timezone: "Asia/Baghdad",
+				},
+			},
 		},
 		Postgres: Postgres{
-			Host: "localhost",
-			Port: 5432,
-			// Username:           "postgres",
+			Host:               "localhost",
+			Port:               5432,
+			Username:           "postgres",
 			Password:           "postgres",
 			DBName:             "arvanch",
+			SSLMode:            "disable",
 			ConnectTimeout:     30 * time.Second,
 			ConnectionLifetime: 30 * time.Minute,
 			MaxOpenConnections: 10,
 			MaxIdleConnections: 5,
+			RetryAttempts:      10,
+			RetryBackoff:       500 * time.Millisecond,
+			RetryMaxBackoff:    5 * time.Second,
+			Replicas:           []string{},
+			MaxReplicaLag:      time.Second,
 		},
 		Logger: Logger{
-			Level: "debug",
+			Level:   "debug",
+			Outputs: []string{"stderr"},
+			File: LogFile{
+				Path:       "/logs/arvanch.log",
+				MaxSize:    100,
+				MaxBackups: 5,
+				MaxAge:     28,
+				Compress:   true,
+			},
+			Syslog: Syslog{
+				Tag: "arvanch",
+			},
+			Sampling: Sampling{
+				Thereafter: 100,
+				Tick:       time.Second,
+			},
 		},
 		// AccessLogger: log.AccessLogger{
 		// 	Enabled: false,
@@ -55,8 +117,6 @@ func Default() Config {
 			StdoutEnable:  false,
 			Path:          "/logs/custom-access.log",
 			SecurePayload: false,
-			EncryptionKey: "secret",
-			HMACKey:       "secret",
 		},
 		NATS: NATS{
 			URL:            "127.0.0.1:4222",
@@ -70,6 +130,18 @@ func Default() Config {
 				Address: ":9001",
 			},
 		},
+		Tracing: Tracing{
+			Enabled:     false,
+			Endpoint:    "localhost:4317",
+			Insecure:    true,
+			SampleRatio: 1,
+			Timeout:     10 * time.Second,
+		},
+		Health: Health{
+			Timeout:        2 * time.Second,
+			MigrationsPath: "migrations",
+			DrainPeriod:    3 * time.Second,
+		},
 		JTIForOTP:  []string{},
 		JTIForBulk: []string{},
 
@@ -110,8 +182,12 @@ func Default() Config {
 				Behaviour: 0,
 			},
 		},
-		UserWhiteList: WhiteList{
-			SMS: []string{},
+		Suppression: Suppression{
+			Keywords: []string{"STOP", "لغو"},
+		},
+		Encryption: Encryption{
+			Enabled:   false,
+			BatchSize: 500,
 		},
 	}
 }
@@ -1,7 +1,10 @@
 package db
 
 import (
+	"context"
 	"fmt"
+	"strconv"
+	"strings"
 	"time"
 
 	"arvanch/config"
@@ -13,27 +16,43 @@ import (
 	"github.com/jinzhu/gorm"
 )
 
-const HealthCheckPeriod = 1 * time.Second
+// WithRetry calls fn until it connects, waiting between attempts with an exponential backoff.
+// It gives up when the attempts are exhausted or ctx is done.
+func WithRetry(ctx context.Context, fn func(cfg config.Postgres) (*gorm.DB, error),
+	cfg config.Postgres) (*gorm.DB, error) {
+	attempts := max(cfg.RetryAttempts, 1)
+	backoff := cfg.RetryBackoff
 
-func WithRetry(fn func(cfg config.Postgres) (*gorm.DB, error), cfg config.Postgres) *gorm.DB {
-	const maxAttempts = 60
-	for range maxAttempts {
-		db, err := fn(cfg)
+	var err error
+
+	for attempt := 1; ; attempt++ {
+		var db *gorm.DB
+
+		db, err = fn(cfg)
 		if err == nil {
-			return db
+			return db, nil
+		}
+
+		if attempt == attempts {
+			break
 		}
 
-		logrus.Errorf("Could not connect to DB. Waiting 1 second. Reason is => %s", err.Error())
-		<-time.After(HealthCheckPeriod)
+		logrus.Errorf("Could not connect to DB. Waiting %s. Reason is => %s", backoff, err.Error())
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(backoff):
+		}
+
+		backoff = min(2*backoff, max(cfg.RetryMaxBackoff, cfg.RetryBackoff))
 	}
 
-	panic(fmt.Sprintf("Could not connect to postgres after %d attempts", maxAttempts))
+	return nil, fmt.Errorf("could not connect to postgres after %d attempts: %w", attempts, err)
 }
 
 func Create(cfg config.Postgres) (*gorm.DB, error) {
-	url := "host=127.0.0.1 port=5432 dbname=arvanch password=postgres sslmode=disable"
-
-	db, err := gorm.Open("postgres", url)
+	db, err := gorm.Open("postgres", DSN(cfg))
 	if err != nil {
 		return nil, err
 	}
@@ -44,3 +63,62 @@ func Create(cfg config.Postgres) (*gorm.DB, error) {
 
 	return db, nil
 }
+
+// DSN returns the connection string of the database.
+// The URL is used as is when it is set, otherwise empty options are left to the driver's defaults.
+func DSN(cfg config.Postgres) string {
+	if cfg.URL != "" {
+		return cfg.URL
+	}
+
+	port, connectTimeout := "", ""
+
+	if cfg.Port != 0 {
+		port = strconv.Itoa(cfg.Port)
+	}
+
+	// the driver only accepts whole seconds, round up so that a sub-second timeout does not disable it.
+	if cfg.ConnectTimeout > 0 {
+		connectTimeout = strconv.Itoa(int((cfg.ConnectTimeout + time.Second - 1) / time.Second))
+	}
+
+	options := []struct {
+		key   string
+		value string
+	}{
+		{"host", cfg.Host},
+		{"port", port},
+		{"user", cfg.Username},
+		{"password", cfg.Password},
+		{"dbname", cfg.DBName},
+		{"sslmode", cfg.SSLMode},
+		{"sslrootcert", cfg.SSLRootCert},
+		{"sslcert", cfg.SSLCert},
+		{"sslkey", cfg.SSLKey},
+		{"connect_timeout", connectTimeout},
+	}
+
+	pairs := make([]string, 0, len(options))
+
+	for _, option := range options {
+		if option.value == "" {
+			continue
+		}
+
+		pairs = append(pairs, option.key+"="+quote(option.value))
+	}
+
+	return strings.Join(pairs, " ")
+}
+
+// quote escapes a connection string value, see https://www.postgresql.org/docs/current/libpq-connect.html#LIBPQ-CONNSTRING.
+func quote(value string) string {
+	if value != "" && !strings.ContainsAny(value, ` '\`) {
+		return value
+	}
+
+	value = strings.ReplaceAll(value, `\`, `\\`)
+	value = strings.ReplaceAll(value, `'`, `\'`)
+
+	return "'" + value + "'"
+}
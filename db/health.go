@@ -0,0 +1,80 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/golang-migrate/migrate/v4/source"
+	_ "github.com/golang-migrate/migrate/v4/source/file" // Imported for its side effects
+	"github.com/jinzhu/gorm"
+)
+
+var ErrPendingMigrations = errors.New("there are pending migrations")
+
+// Ping checks the connectivity of the database.
+func Ping(db *gorm.DB) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		return db.DB().PingContext(ctx)
+	}
+}
+
+// Migrations checks that the database is migrated to the latest migration in the given folder.
+// The folder is read once, as migrations do not change while the server is running.
+func Migrations(db *gorm.DB, path string) (func(ctx context.Context) error, error) {
+	latest, err := latestMigration(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return func(ctx context.Context) error {
+		var (
+			version uint
+			dirty   bool
+		)
+
+		err := db.DB().QueryRowContext(ctx, "SELECT version, dirty FROM schema_migrations LIMIT 1").
+			Scan(&version, &dirty)
+		if err != nil {
+			return fmt.Errorf("failed to read migration version: %w", err)
+		}
+
+		if dirty {
+			return fmt.Errorf("migration %d is dirty", version)
+		}
+
+		if version < latest {
+			return fmt.Errorf("%w: database is at %d, latest is %d", ErrPendingMigrations, version, latest)
+		}
+
+		return nil
+	}, nil
+}
+
+func latestMigration(path string) (uint, error) {
+	src, err := source.Open("file://" + path)
+	if err != nil {
+		return 0, fmt.Errorf("failed to open migrations: %w", err)
+	}
+
+	defer src.Close()
+
+	version, err := src.First()
+	if err != nil {
+		return 0, fmt.Errorf("failed to read migrations: %w", err)
+	}
+
+	for {
+		next, err := src.Next(version)
+		if errors.Is(err, os.ErrNotExist) {
+			return version, nil
+		}
+
+		if err != nil {
+			return 0, fmt.Errorf("failed to read migrations: %w", err)
+		}
+
+		version = next
+	}
+}
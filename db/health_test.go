@@ -0,0 +1,31 @@
+package db
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLatestMigration(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+
+	for _, name := range []string{
+		"20200101000000_first.up.sql",
+		"20200101000000_first.down.sql",
+		"20200102000000_second.up.sql",
+		"20200102000000_second.down.sql",
+	} {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), nil, 0o600))
+	}
+
+	version, err := latestMigration(dir)
+	require.NoError(t, err)
+	require.EqualValues(t, 20200102000000, version)
+
+	_, err = latestMigration(t.TempDir())
+	require.Error(t, err)
+}
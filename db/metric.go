@@ -0,0 +1,21 @@
+package db
+
+import (
+	"errors"
+
+	"github.com/jinzhu/gorm"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+)
+
+// RegisterMetrics exposes the connection pool stats of the database as prometheus metrics.
+func RegisterMetrics(db *gorm.DB, dbName string) error {
+	err := prometheus.Register(collectors.NewDBStatsCollector(db.DB(), dbName))
+
+	var alreadyRegistered prometheus.AlreadyRegisteredError
+	if errors.As(err, &alreadyRegistered) {
+		return nil
+	}
+
+	return err
+}
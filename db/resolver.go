@@ -0,0 +1,167 @@
+package db
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"arvanch/config"
+	"arvanch/log"
+
+	"github.com/jinzhu/gorm"
+	"github.com/patrickmn/go-cache"
+	"github.com/sirupsen/logrus"
+)
+
+// lagCheckInterval is how long the measured lag of a replica is trusted.
+const lagCheckInterval = time.Second
+
+// replicationLagQuery returns zero when the replica has replayed all the received WAL,
+// so an idle primary does not make its replicas look behind.
+const replicationLagQuery = `SELECT CASE
+	WHEN pg_last_wal_receive_lsn() = pg_last_wal_replay_lsn() THEN 0
+	ELSE COALESCE(EXTRACT(EPOCH FROM now() - pg_last_xact_replay_timestamp()), 0)
+END`
+
+// Resolver routes read-only queries to replicas and everything else to the primary.
+type Resolver struct {
+	primary  *gorm.DB
+	replicas []*replica
+	next     atomic.Uint64
+	maxLag   time.Duration
+	written  *cache.Cache
+	lag      func(ctx context.Context, db *gorm.DB) (time.Duration, error)
+}
+
+type replica struct {
+	db        *gorm.DB
+	mu        sync.Mutex
+	checkedAt time.Time
+	usable    bool
+}
+
+// NewResolver creates a resolver, all queries go to the primary when there is no replica.
+func NewResolver(primary *gorm.DB, replicas []*gorm.DB, maxLag time.Duration) *Resolver {
+	r := &Resolver{
+		primary: primary,
+		maxLag:  maxLag,
+		written: cache.New(maxLag, 10*maxLag+time.Second),
+		lag:     replicationLag,
+	}
+
+	for _, db := range replicas {
+		r.replicas = append(r.replicas, &replica{db: db})
+	}
+
+	return r
+}
+
+// ConnectReplicas connects to the configured replicas. Unreachable replicas are skipped.
+func ConnectReplicas(cfg config.Postgres) []*gorm.DB {
+	replicas := make([]*gorm.DB, 0, len(cfg.Replicas))
+
+	for _, url := range cfg.Replicas {
+		replicaCfg := cfg
+		replicaCfg.URL = url
+
+		db, err := Create(replicaCfg)
+		if err != nil {
+			logrus.Errorf("failed to connect to postgres replica, reads will use the remaining ones: %s", err.Error())
+
+			continue
+		}
+
+		replicas = append(replicas, db)
+	}
+
+	return replicas
+}
+
+func (r *Resolver) Primary() *gorm.DB {
+	return r.primary
+}
+
+// Wrote marks data identified by key as written, its reads go to the primary until replicas catch up with it.
+func (r *Resolver) Wrote(key string) {
+	if r.maxLag <= 0 {
+		return
+	}
+
+	r.written.SetDefault(key, struct{}{})
+}
+
+// Read runs the read-only fn on a replica that is not lagging behind.
+// It runs fn on the primary when the key was written recently, there is no usable replica or fn fails on the replica.
+// Keys are only marked as written in the process which wrote them, so fn should return gorm.ErrRecordNotFound
+// when data which must be read consistently is missing on the replica, e.g. an account just created by another
+// process; it is then read from the primary.
+func (r *Resolver) Read(ctx context.Context, key string, fn func(db *gorm.DB) error) error {
+	if _, found := r.written.Get(key); !found {
+		if db := r.replica(ctx); db != nil {
+			err := fn(db)
+			if err == nil {
+				return nil
+			}
+
+			if !gorm.IsRecordNotFoundError(err) {
+				log.FromContext(ctx).Warnf("read from postgres replica failed, falling back to primary: %s", err.Error())
+			}
+		}
+	}
+
+	return fn(r.primary)
+}
+
+// Close closes the connections of replicas, the primary is owned by the caller.
+func (r *Resolver) Close() error {
+	for _, replica := range r.replicas {
+		if err := replica.db.Close(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// replica picks the replicas in turn and returns the first usable one.
+func (r *Resolver) replica(ctx context.Context) *gorm.DB {
+	for range r.replicas {
+		replica := r.replicas[r.next.Add(1)%uint64(len(r.replicas))]
+
+		if r.usable(ctx, replica) {
+			return replica.db
+		}
+	}
+
+	return nil
+}
+
+func (r *Resolver) usable(ctx context.Context, replica *replica) bool {
+	replica.mu.Lock()
+	defer replica.mu.Unlock()
+
+	if time.Since(replica.checkedAt) < lagCheckInterval {
+		return replica.usable
+	}
+
+	lag, err := r.lag(ctx, replica.db)
+	if err != nil {
+		log.FromContext(ctx).Errorf("failed to check postgres replica lag: %s", err.Error())
+	}
+
+	replica.checkedAt = time.Now()
+	replica.usable = err == nil && lag <= r.maxLag
+
+	return replica.usable
+}
+
+func replicationLag(ctx context.Context, db *gorm.DB) (time.Duration, error) {
+	var seconds float64
+
+	if err := db.DB().QueryRowContext(ctx, replicationLagQuery).Scan(&seconds); err != nil {
+		return 0, err
+	}
+
+	return time.Duration(seconds * float64(time.Second)), nil
+}
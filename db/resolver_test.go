@@ -0,0 +1,137 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/jinzhu/gorm"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolverRead(t *testing.T) {
+	t.Parallel()
+
+	primary, replica := &gorm.DB{Value: "primary"}, &gorm.DB{Value: "replica"}
+
+	tcs := []struct {
+		name     string
+		replicas []*gorm.DB
+		lag      time.Duration
+		lagErr   error
+		written  bool
+		failOn   *gorm.DB
+		expected []*gorm.DB
+	}{
+		{
+			name:     "no replica",
+			expected: []*gorm.DB{primary},
+		},
+		{
+			name:     "replica",
+			replicas: []*gorm.DB{replica},
+			expected: []*gorm.DB{replica},
+		},
+		{
+			name:     "lagging replica",
+			replicas: []*gorm.DB{replica},
+			lag:      time.Minute,
+			expected: []*gorm.DB{primary},
+		},
+		{
+			name:     "lag check failed",
+			replicas: []*gorm.DB{replica},
+			lagErr:   errors.New("connection refused"),
+			expected: []*gorm.DB{primary},
+		},
+		{
+			name:     "recently written",
+			replicas: []*gorm.DB{replica},
+			written:  true,
+			expected: []*gorm.DB{primary},
+		},
+		{
+			name:     "fallback on error",
+			replicas: []*gorm.DB{replica},
+			failOn:   replica,
+			expected: []*gorm.DB{replica, primary},
+		},
+	}
+
+	for i := range tcs {
+		tc := tcs[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			resolver := NewResolver(primary, tc.replicas, time.Second)
+			resolver.lag = func(context.Context, *gorm.DB) (time.Duration, error) { return tc.lag, tc.lagErr }
+
+			if tc.written {
+				resolver.Wrote("user")
+			}
+
+			var used []*gorm.DB
+
+			err := resolver.Read(context.Background(), "user", func(db *gorm.DB) error {
+				used = append(used, db)
+
+				if db == tc.failOn {
+					return gorm.ErrRecordNotFound
+				}
+
+				return nil
+			})
+
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, used)
+		})
+	}
+}
+
+// TestResolverReadAcrossProcesses models the accounting and messanger processes, each with its own resolver.
+// Keys written by one are not known to the other, so data missing on the replica is read from the primary.
+func TestResolverReadAcrossProcesses(t *testing.T) {
+	t.Parallel()
+
+	primary, replica := &gorm.DB{Value: "primary"}, &gorm.DB{Value: "replica"}
+
+	accounting := NewResolver(primary, []*gorm.DB{replica}, time.Second)
+	messanger := NewResolver(primary, []*gorm.DB{replica}, time.Second)
+
+	for _, resolver := range []*Resolver{accounting, messanger} {
+		resolver.lag = func(context.Context, *gorm.DB) (time.Duration, error) { return 0, nil }
+	}
+
+	// the account is created by accounting and is only on the primary yet.
+	accounting.Wrote("user")
+
+	read := func(resolver *Resolver, onPrimary bool) ([]*gorm.DB, error) {
+		var used []*gorm.DB
+
+		err := resolver.Read(context.Background(), "user", func(db *gorm.DB) error {
+			used = append(used, db)
+
+			if db == replica || !onPrimary {
+				return gorm.ErrRecordNotFound
+			}
+
+			return nil
+		})
+
+		return used, err
+	}
+
+	used, err := read(accounting, true)
+	require.NoError(t, err)
+	require.Equal(t, []*gorm.DB{primary}, used)
+
+	used, err = read(messanger, true)
+	require.NoError(t, err)
+	require.Equal(t, []*gorm.DB{replica, primary}, used, "missing data should be read from the primary")
+
+	used, err = read(messanger, false)
+	require.ErrorIs(t, err, gorm.ErrRecordNotFound)
+	require.Equal(t, []*gorm.DB{replica, primary}, used)
+}
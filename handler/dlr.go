@@ -0,0 +1,87 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"arvanch/log/access"
+	"arvanch/log/dpn"
+	"arvanch/model"
+	"arvanch/repository"
+	"arvanch/request"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/labstack/echo/v4"
+)
+
+// DLRHandler receives delivery reports of messages from providers.
+type DLRHandler struct {
+	msgRepo      repository.MessageRepository
+	dpnLogger    *dpn.Logger
+	reqValidator *validator.Validate
+}
+
+func NewDLRHandler(
+	msgRepo repository.MessageRepository,
+	dpnLogger *dpn.Logger,
+	reqValidator *validator.Validate,
+) DLRHandler {
+	return DLRHandler{
+		msgRepo:      msgRepo,
+		dpnLogger:    dpnLogger,
+		reqValidator: reqValidator,
+	}
+}
+
+// SMS sets the final status of an sms and records its delivery notification.
+// Reports of messages which already have a final status are ignored.
+func (d DLRHandler) SMS(c echo.Context) error {
+	entry := access.EntryFrom(c)
+
+	var req request.DLR
+	if err := c.Bind(&req); err != nil {
+		return errInvalidBody.WithErr(err)
+	}
+
+	if err := req.Validate(d.reqValidator); err != nil {
+		return err
+	}
+
+	entry.UUID = req.MessageID
+
+	msg, updated, err := d.msgRepo.UpdateMessageStatus(c.Request().Context(), req.MessageID, req.Status)
+	if errors.Is(err, model.ErrRecordNotFound) {
+		return NewAPIError(http.StatusNotFound, ErrCodeNotFound, "message not found")
+	}
+
+	if err != nil {
+		return err
+	}
+
+	if !updated {
+		return c.NoContent(http.StatusNoContent)
+	}
+
+	metrics.DeliveryReports.WithLabelValues(req.Status, msg.Provider).Inc()
+
+	doneAt := req.DoneAt
+	if doneAt.IsZero() {
+		doneAt = time.Now()
+	}
+
+	d.dpnLogger.Log(dpn.MediaSMS, dpn.Record{
+		MessageID: msg.ID,
+		UserID:    msg.UserID,
+		Recipient: msg.Recipient,
+		Status:    msg.Status,
+		Provider:  msg.Provider,
+		ErrorCode: req.ErrorCode,
+		Segments:  msg.Segments,
+		Price:     msg.Price,
+		SentAt:    msg.CreatedAt,
+		DoneAt:    doneAt,
+	})
+
+	return c.NoContent(http.StatusNoContent)
+}
@@ -0,0 +1,75 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"arvanch/config"
+	"arvanch/log/dpn"
+	"arvanch/model"
+	"arvanch/repository"
+	"arvanch/request"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeStatusRepo struct {
+	repository.MessageRepository
+	messages map[string]*model.Message
+}
+
+func (f *fakeStatusRepo) UpdateMessageStatus(_ context.Context, id, status string) (model.Message, bool, error) {
+	msg, ok := f.messages[id]
+	if !ok {
+		return model.Message{}, false, model.ErrRecordNotFound
+	}
+
+	if model.IsFinalStatus(msg.Status) {
+		return *msg, false, nil
+	}
+
+	msg.Status = status
+
+	return *msg, true, nil
+}
+
+func TestDLR(t *testing.T) {
+	t.Parallel()
+
+	reqValidator, err := request.NewValidator()
+	require.NoError(t, err)
+
+	dpnLogger, err := dpn.NewDPNLogger(config.DPNLogger{}, true)
+	require.NoError(t, err)
+
+	repo := &fakeStatusRepo{messages: map[string]*model.Message{
+		"5f0c7a0e-3c1d-4a8e-9d6b-2a7f1e4c9b30": {Status: model.MessageStatusAccepted},
+	}}
+
+	e := echo.New()
+	e.HTTPErrorHandler = HTTPErrorHandler
+	e.POST("/dlr", NewDLRHandler(repo, dpnLogger, reqValidator).SMS)
+
+	report := func(body string) int {
+		req := httptest.NewRequest(http.MethodPost, "/dlr", strings.NewReader(body))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+
+		w := httptest.NewRecorder()
+		e.ServeHTTP(w, req)
+
+		return w.Code
+	}
+
+	require.Equal(t, http.StatusBadRequest, report(`{"message_id": "5f0c7a0e-3c1d-4a8e-9d6b-2a7f1e4c9b30", "status": "sent"}`))
+	require.Equal(t, http.StatusBadRequest, report(`{"message_id": "1", "status": "delivered"}`))
+	require.Equal(t, http.StatusNotFound, report(`{"message_id": "0b7e2f4a-1c3d-4e5f-8a9b-6c7d8e9f0a1b", "status": "delivered"}`))
+
+	require.Equal(t, http.StatusNoContent, report(`{"message_id": "5f0c7a0e-3c1d-4a8e-9d6b-2a7f1e4c9b30", "status": "failed"}`))
+	require.Equal(t, http.StatusNoContent, report(`{"message_id": "5f0c7a0e-3c1d-4a8e-9d6b-2a7f1e4c9b30", "status": "delivered"}`))
+	require.Equal(t, model.MessageStatusFailed, repo.messages["5f0c7a0e-3c1d-4a8e-9d6b-2a7f1e4c9b30"].Status,
+		"the first final status should be kept")
+}
@@ -0,0 +1,184 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"arvanch/i18n"
+	"arvanch/log"
+	"arvanch/model"
+	"arvanch/pkg/locale"
+	"arvanch/pkg/requestid"
+	"arvanch/repository"
+	"arvanch/request"
+
+	"github.com/labstack/echo/v4"
+)
+
+const headerAcceptLanguage = "Accept-Language"
+
+// Error codes are stable for clients to match on, unlike messages.
+const (
+	ErrCodeBadRequest        = "bad_request"
+	ErrCodeMissingHeader     = "missing_header"
+	ErrCodeInvalidParameters = "invalid_parameters"
+	ErrCodeInvalidRecipient  = "invalid_recipient"
+	ErrCodeInvalidCursor     = "invalid_cursor"
+	// ErrCodePayloadSearchEncrypted is returned when searching payloads of encrypted messages.
+	ErrCodePayloadSearchEncrypted = "payload_search_encrypted"
+	ErrCodeUnauthorized           = "unauthorized"
+	ErrCodeForbidden              = "forbidden"
+	ErrCodeNotFound               = "not_found"
+	ErrCodeAlreadyExists          = "already_exists"
+	ErrCodeInsufficientBalance    = "insufficient_balance"
+	ErrCodeInternal               = "internal_error"
+)
+
+// statusCodes are the codes of errors known only by their HTTP status, e.g. errors of echo's middlewares.
+var statusCodes = map[int]string{
+	http.StatusBadRequest:   ErrCodeBadRequest,
+	http.StatusUnauthorized: ErrCodeUnauthorized,
+	http.StatusForbidden:    ErrCodeForbidden,
+	http.StatusNotFound:     ErrCodeNotFound,
+	http.StatusConflict:     ErrCodeAlreadyExists,
+}
+
+// APIError is the body of error responses.
+type APIError struct {
+	Status    int         `json:"-"`
+	Code      string      `json:"code"`
+	Message   string      `json:"message"`
+	Details   interface{} `json:"details,omitempty"`
+	RequestID string      `json:"request_id,omitempty"`
+	// Err is the cause of the error, it is logged but never returned to clients.
+	Err error `json:"-"`
+}
+
+func NewAPIError(status int, code, message string) *APIError {
+	return &APIError{Status: status, Code: code, Message: message}
+}
+
+func (e *APIError) Error() string {
+	if e.Err != nil {
+		return fmt.Sprintf("%s: %s: %s", e.Code, e.Message, e.Err.Error())
+	}
+
+	return fmt.Sprintf("%s: %s", e.Code, e.Message)
+}
+
+func (e *APIError) Unwrap() error {
+	return e.Err
+}
+
+// WithErr returns a copy of the error caused by err.
+func (e *APIError) WithErr(err error) *APIError {
+	c := *e
+	c.Err = err
+
+	return &c
+}
+
+// WithDetails returns a copy of the error with details for clients.
+func (e *APIError) WithDetails(details interface{}) *APIError {
+	c := *e
+	c.Details = details
+
+	return &c
+}
+
+var errInvalidBody = NewAPIError(http.StatusBadRequest, ErrCodeBadRequest, "request's body is not valid")
+
+var errInvalidQuery = NewAPIError(http.StatusBadRequest, ErrCodeBadRequest, "request's query is not valid")
+
+// Users are identified by X-USER-ID, requests without a valid one are unauthorized.
+var (
+	errMissingUserID = NewAPIError(http.StatusUnauthorized, ErrCodeMissingHeader, fmt.Sprintf("Missing %v header", log.UserIDHeader))
+	errInvalidUserID = NewAPIError(http.StatusUnauthorized, ErrCodeUnauthorized, fmt.Sprintf("%v header is not a valid user id", log.UserIDHeader))
+	errUserNotFound  = NewAPIError(http.StatusNotFound, ErrCodeNotFound, "user not found")
+)
+
+func missingHeader(header string) *APIError {
+	return NewAPIError(http.StatusBadRequest, ErrCodeMissingHeader, fmt.Sprintf("Missing %v header", header))
+}
+
+// toAPIError maps errors to the API error returned to clients. Errors it does not know
+// are internal errors, their message is hidden as it may leak internals, e.g. database errors.
+// nolint:cyclop
+func toAPIError(err error) *APIError {
+	var (
+		apiErr  *APIError
+		httpErr *echo.HTTPError
+	)
+
+	switch {
+	case errors.As(err, &apiErr):
+		return apiErr
+	case errors.As(err, &httpErr):
+		code, ok := statusCodes[httpErr.Code]
+		if !ok {
+			code = ErrCodeInternal
+		}
+
+		return &APIError{Status: httpErr.Code, Code: code, Message: fmt.Sprint(httpErr.Message), Err: httpErr.Internal}
+	case errors.Is(err, request.ErrRegionNotAllowed):
+		return NewAPIError(http.StatusForbidden, ErrCodeRegionNotAllowed, err.Error())
+	case errors.Is(err, request.ErrInvalidRecipient), errors.Is(err, i18n.ErrInvalidPhoneNumber):
+		return NewAPIError(http.StatusBadRequest, ErrCodeInvalidRecipient, err.Error())
+	case errors.Is(err, request.ErrInvalidParameters):
+		return NewAPIError(http.StatusBadRequest, ErrCodeInvalidParameters, err.Error())
+	case errors.Is(err, repository.ErrInvalidCursor):
+		return NewAPIError(http.StatusBadRequest, ErrCodeInvalidCursor, err.Error())
+	case errors.Is(err, repository.ErrPayloadSearchEncrypted):
+		return NewAPIError(http.StatusBadRequest, ErrCodePayloadSearchEncrypted, err.Error())
+	case errors.Is(err, model.ErrRecordNotFound):
+		return NewAPIError(http.StatusNotFound, ErrCodeNotFound, "record not found").WithErr(err)
+	case errors.Is(err, model.ErrDuplicateEntry):
+		return NewAPIError(http.StatusConflict, ErrCodeAlreadyExists, "record already exists").WithErr(err)
+	case errors.Is(err, model.ErrInsufficientBalance):
+		return NewAPIError(http.StatusPaymentRequired, ErrCodeInsufficientBalance, "account's balance is not enough").
+			WithErr(err)
+	default:
+		return NewAPIError(http.StatusInternalServerError, ErrCodeInternal, http.StatusText(http.StatusInternalServerError)).
+			WithErr(err)
+	}
+}
+
+// HTTPErrorHandler writes errors returned by handlers and middlewares as APIError, with the request's ID.
+// Validation errors are detailed per field, in the request's locale or else the locale of its Accept-Language.
+func HTTPErrorHandler(err error, c echo.Context) {
+	if c.Response().Committed {
+		return
+	}
+
+	ctx := c.Request().Context()
+
+	resp := *toAPIError(err)
+	resp.RequestID = requestid.FromContext(ctx)
+
+	var validationErr *request.ValidationError
+	if errors.As(err, &validationErr) {
+		l := validationErr.Locale()
+		if l == "" {
+			l = locale.FromAcceptLanguage(c.Request().Header.Get(headerAcceptLanguage))
+		}
+
+		resp.Details = validationErr.Fields(l)
+	}
+
+	if resp.Status >= http.StatusInternalServerError {
+		log.FromContext(ctx).Errorf("request failed: %s", err.Error())
+	}
+
+	var writeErr error
+
+	if c.Request().Method == http.MethodHead {
+		writeErr = c.NoContent(resp.Status)
+	} else {
+		writeErr = c.JSON(resp.Status, resp)
+	}
+
+	if writeErr != nil {
+		log.FromContext(ctx).Errorf("failed to write error response: %s", writeErr.Error())
+	}
+}
@@ -0,0 +1,232 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"arvanch/i18n"
+	"arvanch/log"
+	"arvanch/model"
+	"arvanch/pkg/requestid"
+	"arvanch/repository"
+	"arvanch/request"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/require"
+)
+
+func TestToAPIError(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name   string
+		err    error
+		status int
+		code   string
+	}{
+		{
+			name:   "api error",
+			err:    fmt.Errorf("wrapped: %w", missingHeader(log.UserIDHeader)),
+			status: http.StatusBadRequest,
+			code:   ErrCodeMissingHeader,
+		},
+		{
+			name:   "echo error",
+			err:    echo.NewHTTPError(http.StatusUnauthorized, "invalid token"),
+			status: http.StatusUnauthorized,
+			code:   ErrCodeUnauthorized,
+		},
+		{
+			name:   "region not allowed",
+			err:    request.ErrRegionNotAllowed,
+			status: http.StatusForbidden,
+			code:   ErrCodeRegionNotAllowed,
+		},
+		{
+			name:   "invalid phone number",
+			err:    i18n.ErrInvalidPhoneNumber,
+			status: http.StatusBadRequest,
+			code:   ErrCodeInvalidRecipient,
+		},
+		{
+			name:   "invalid parameters",
+			err:    fmt.Errorf("%w: payload is required", request.ErrInvalidParameters),
+			status: http.StatusBadRequest,
+			code:   ErrCodeInvalidParameters,
+		},
+		{
+			name:   "invalid cursor",
+			err:    repository.ErrInvalidCursor,
+			status: http.StatusBadRequest,
+			code:   ErrCodeInvalidCursor,
+		},
+		{
+			name:   "payload search encrypted",
+			err:    repository.ErrPayloadSearchEncrypted,
+			status: http.StatusBadRequest,
+			code:   ErrCodePayloadSearchEncrypted,
+		},
+		{
+			name:   "not found",
+			err:    model.ErrRecordNotFound,
+			status: http.StatusNotFound,
+			code:   ErrCodeNotFound,
+		},
+		{
+			name:   "duplicate",
+			err:    fmt.Errorf("%w: users_pkey", model.ErrDuplicateEntry),
+			status: http.StatusConflict,
+			code:   ErrCodeAlreadyExists,
+		},
+		{
+			name:   "insufficient balance",
+			err:    model.ErrInsufficientBalance,
+			status: http.StatusPaymentRequired,
+			code:   ErrCodeInsufficientBalance,
+		},
+		{
+			name:   "unknown",
+			err:    errors.New("pq: connection refused"),
+			status: http.StatusInternalServerError,
+			code:   ErrCodeInternal,
+		},
+	}
+
+	for i := range cases {
+		tc := cases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			apiErr := toAPIError(tc.err)
+			require.Equal(t, tc.status, apiErr.Status)
+			require.Equal(t, tc.code, apiErr.Code)
+		})
+	}
+}
+
+func TestHTTPErrorHandler(t *testing.T) {
+	t.Parallel()
+
+	e := echo.New()
+	e.HTTPErrorHandler = HTTPErrorHandler
+	e.Use(requestid.Middleware())
+
+	e.GET("/internal", func(c echo.Context) error {
+		return errors.New("pq: password authentication failed")
+	})
+	e.GET("/missing", func(c echo.Context) error {
+		return missingHeader(log.UserIDHeader)
+	})
+
+	get := func(path string) (*httptest.ResponseRecorder, APIError) {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.Header.Set(echo.HeaderXRequestID, "req-1")
+
+		w := httptest.NewRecorder()
+		e.ServeHTTP(w, req)
+
+		var body APIError
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+
+		return w, body
+	}
+
+	w, body := get("/internal")
+	require.Equal(t, http.StatusInternalServerError, w.Code)
+	require.Equal(t, ErrCodeInternal, body.Code)
+	require.Equal(t, "req-1", body.RequestID)
+	require.NotContains(t, w.Body.String(), "password", "internal errors should not be returned")
+
+	w, body = get("/missing")
+	require.Equal(t, http.StatusBadRequest, w.Code)
+	require.Equal(t, ErrCodeMissingHeader, body.Code)
+	require.Equal(t, "req-1", body.RequestID)
+
+	w, body = get("/unknown")
+	require.Equal(t, http.StatusNotFound, w.Code)
+	require.Equal(t, ErrCodeNotFound, body.Code)
+}
+
+func TestHTTPErrorHandlerValidation(t *testing.T) {
+	t.Parallel()
+
+	reqValidator, err := request.NewValidator()
+	require.NoError(t, err)
+
+	e := echo.New()
+	e.HTTPErrorHandler = HTTPErrorHandler
+
+	e.GET("/sms", func(c echo.Context) error {
+		return request.SMS{Payload: "Hi"}.Validate(reqValidator, []string{"arvan"})
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/sms", nil)
+	req.Header.Set(headerAcceptLanguage, "fa-IR,fa;q=0.9")
+
+	w := httptest.NewRecorder()
+	e.ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusBadRequest, w.Code)
+
+	var body struct {
+		Code    string               `json:"code"`
+		Details []request.FieldError `json:"details"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+
+	require.Equal(t, ErrCodeInvalidParameters, body.Code)
+	require.Equal(t, []request.FieldError{
+		{Field: "phone_number", Tag: "required", Message: "فیلد phone_number اجباری است"},
+	}, body.Details)
+}
+
+func TestHTTPErrorHandlerRequestLocale(t *testing.T) {
+	t.Parallel()
+
+	reqValidator, err := request.NewValidator()
+	require.NoError(t, err)
+
+	h := NewSMSHandler(nil, nil, i18n.Arvan, reqValidator, nil, []string{i18n.Arvan.String()}, false)
+
+	e := echo.New()
+	e.HTTPErrorHandler = HTTPErrorHandler
+	e.POST("/sms", h.Sms)
+
+	cases := []struct {
+		name           string
+		acceptLanguage string
+		expected       string
+	}{
+		{name: "without accept language", expected: "فیلد phone_number اجباری است"},
+		{name: "with another accept language", acceptLanguage: "en-US,en;q=0.9", expected: "فیلد phone_number اجباری است"},
+	}
+
+	for _, tc := range cases {
+		req := httptest.NewRequest(http.MethodPost, "/sms", strings.NewReader(`{"payload": "Hi", "locale": "fa"}`))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		req.Header.Set(log.UserIDHeader, DefaultUserID)
+
+		if tc.acceptLanguage != "" {
+			req.Header.Set(headerAcceptLanguage, tc.acceptLanguage)
+		}
+
+		w := httptest.NewRecorder()
+		e.ServeHTTP(w, req)
+
+		require.Equal(t, http.StatusBadRequest, w.Code, tc.name)
+
+		var body struct {
+			Details []request.FieldError `json:"details"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+
+		require.Len(t, body.Details, 1, tc.name)
+		require.Equal(t, tc.expected, body.Details[0].Message, tc.name)
+	}
+}
@@ -0,0 +1,87 @@
+package handler
+
+import (
+	"strconv"
+
+	"arvanch/config"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// Metrics represents prometheus metrics for handlers.
+type Metrics struct {
+	SMSAccepted   *prometheus.CounterVec
+	SMSRejected   *prometheus.CounterVec
+	BalanceDebit  prometheus.Counter
+	BalanceCredit prometheus.Counter
+	// DeliveryReports counts messages reaching a final status.
+	DeliveryReports *prometheus.CounterVec
+}
+
+const (
+	LabelRegion   = "region"
+	LabelProvider = "provider"
+	LabelLocale   = "locale"
+	LabelStatus   = "status"
+)
+
+// nolint:gochecknoglobals
+var (
+	metrics = Metrics{
+		SMSAccepted: promauto.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: config.Namespace,
+				Name:      "sms_accepted_total",
+				Help:      "number of accepted sms requests",
+			}, []string{LabelRegion, LabelProvider, LabelLocale},
+		),
+		SMSRejected: promauto.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: config.Namespace,
+				Name:      "sms_rejected_total",
+				Help:      "number of rejected sms requests per response status code",
+			}, []string{LabelStatus, LabelRegion, LabelProvider, LabelLocale},
+		),
+		BalanceDebit: promauto.NewCounter(
+			prometheus.CounterOpts{
+				Namespace: config.Namespace,
+				Name:      "balance_debit_total",
+				Help:      "total amount debited from accounts' balance",
+			},
+		),
+		BalanceCredit: promauto.NewCounter(
+			prometheus.CounterOpts{
+				Namespace: config.Namespace,
+				Name:      "balance_credit_total",
+				Help:      "total amount charged to accounts' balance",
+			},
+		),
+		DeliveryReports: promauto.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: config.Namespace,
+				Name:      "sms_delivery_reports_total",
+				Help:      "number of sms reaching a final status per status and provider",
+			}, []string{LabelStatus, LabelProvider},
+		),
+	}
+)
+
+func (m Metrics) reportSMS(status int, region, provider, locale string) {
+	if status >= 200 && status < 300 {
+		m.SMSAccepted.With(prometheus.Labels{
+			LabelRegion:   region,
+			LabelProvider: provider,
+			LabelLocale:   locale,
+		}).Inc()
+
+		return
+	}
+
+	m.SMSRejected.With(prometheus.Labels{
+		LabelStatus:   strconv.Itoa(status),
+		LabelRegion:   region,
+		LabelProvider: provider,
+		LabelLocale:   locale,
+	}).Inc()
+}
@@ -0,0 +1,15 @@
+package handler
+
+import (
+	"crypto/subtle"
+
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
+)
+
+// TokenAuth authorizes requests having the given token as their bearer token.
+func TokenAuth(token string) echo.MiddlewareFunc {
+	return middleware.KeyAuth(func(key string, _ echo.Context) (bool, error) {
+		return subtle.ConstantTimeCompare([]byte(key), []byte(token)) == 1, nil
+	})
+}
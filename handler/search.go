@@ -0,0 +1,87 @@
+package handler
+
+import (
+	"net/http"
+
+	"arvanch/i18n"
+	"arvanch/model"
+	"arvanch/repository"
+	"arvanch/request"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+// xActorHeader identifies the support staff member searching, for the audit trail.
+const xActorHeader = "X-ACTOR"
+
+type SearchHandler struct {
+	searchRepo   repository.SearchRepository
+	Region       i18n.Region
+	reqValidator *validator.Validate
+}
+
+func NewSearchHandler(
+	searchRepo repository.SearchRepository,
+	region i18n.Region,
+	reqValidator *validator.Validate,
+) SearchHandler {
+	return SearchHandler{
+		searchRepo:   searchRepo,
+		Region:       region,
+		reqValidator: reqValidator,
+	}
+}
+
+// SearchMessages searches the message history of all users. Every search is audited with its actor.
+func (s SearchHandler) SearchMessages(c echo.Context) error {
+	actor := c.Request().Header.Get(xActorHeader)
+
+	if actor == "" {
+		return missingHeader(xActorHeader)
+	}
+
+	var req request.MessageSearch
+	if err := c.Bind(&req); err != nil {
+		return errInvalidQuery.WithErr(err)
+	}
+
+	if err := req.Validate(s.reqValidator); err != nil {
+		return err
+	}
+
+	filter, err := messageFilter(req.Messages, s.Region)
+	if err != nil {
+		return err
+	}
+
+	limit := req.PageLimit()
+	// fetch one more message to know whether there is a next page.
+	filter.Limit = limit + 1
+
+	msgs, err := s.searchRepo.SearchMessages(c.Request().Context(), repository.MessageSearch{
+		MessageFilter: filter,
+		UserID:        req.UserID,
+		AccountID:     req.AccountID,
+		Payload:       req.Payload,
+	})
+	if err != nil {
+		return err
+	}
+
+	msgs, nextCursor := paginate(msgs, limit)
+
+	// results are only returned once the search is recorded.
+	if err := s.searchRepo.InsertSearchAudit(c.Request().Context(), &model.SearchAudit{
+		ID:            uuid.New().String(),
+		Actor:         actor,
+		RemoteAddress: c.RealIP(),
+		Query:         request.MarshalRawRequest(req),
+		Results:       len(msgs),
+	}); err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, echo.Map{"messages": msgs, "next_cursor": nextCursor})
+}
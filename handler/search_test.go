@@ -0,0 +1,91 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"arvanch/i18n"
+	"arvanch/model"
+	"arvanch/repository"
+	"arvanch/request"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeSearchRepo struct {
+	messages []model.Message
+	search   repository.MessageSearch
+	audits   []model.SearchAudit
+}
+
+func (f *fakeSearchRepo) SearchMessages(_ context.Context, search repository.MessageSearch) ([]model.Message, error) {
+	f.search = search
+
+	return f.messages, nil
+}
+
+func (f *fakeSearchRepo) InsertSearchAudit(_ context.Context, audit *model.SearchAudit) error {
+	f.audits = append(f.audits, *audit)
+
+	return nil
+}
+
+func TestSearchMessages(t *testing.T) {
+	t.Parallel()
+
+	reqValidator, err := request.NewValidator()
+	require.NoError(t, err)
+
+	createdAt := time.Date(2026, 10, 17, 10, 0, 0, 0, time.UTC)
+
+	repo := &fakeSearchRepo{messages: []model.Message{
+		{ID: "2", CreatedAt: createdAt.Add(time.Minute)},
+		{ID: "1", CreatedAt: createdAt},
+	}}
+
+	e := echo.New()
+	e.HTTPErrorHandler = HTTPErrorHandler
+	e.GET("/search", NewSearchHandler(repo, i18n.Arvan, reqValidator).SearchMessages)
+
+	search := func(query, actor string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/search?"+query, nil)
+		if actor != "" {
+			req.Header.Set(xActorHeader, actor)
+		}
+
+		w := httptest.NewRecorder()
+		e.ServeHTTP(w, req)
+
+		return w
+	}
+
+	require.Equal(t, http.StatusBadRequest, search("payload=code", "").Code)
+	require.Equal(t, http.StatusBadRequest, search("payload=ab", "support").Code)
+	require.Empty(t, repo.audits)
+
+	w := search("recipient=09121234567&payload=code&account_id=2b1c4fd5-7a3e-4c8e-9f5a-0e7c1d2b3a4f&limit=1", "support")
+	require.Equal(t, http.StatusOK, w.Code)
+
+	require.Equal(t, "+989121234567", repo.search.Recipient)
+	require.Equal(t, "code", repo.search.Payload)
+	require.Equal(t, "2b1c4fd5-7a3e-4c8e-9f5a-0e7c1d2b3a4f", repo.search.AccountID)
+	require.Equal(t, 2, repo.search.Limit)
+
+	var resp struct {
+		Messages   []model.Message `json:"messages"`
+		NextCursor string          `json:"next_cursor"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	require.Len(t, resp.Messages, 1)
+	require.NotEmpty(t, resp.NextCursor)
+
+	require.Len(t, repo.audits, 1)
+	require.Equal(t, "support", repo.audits[0].Actor)
+	require.Equal(t, 1, repo.audits[0].Results)
+	require.Contains(t, repo.audits[0].Query, `"payload":"code"`)
+}
@@ -1,14 +1,19 @@
 package handler
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"net/http"
 
 	"arvanch/i18n"
+	"arvanch/log"
 	"arvanch/log/access"
 	"arvanch/model"
 	"arvanch/pkg/locale"
+	"arvanch/pkg/mask"
+	"arvanch/pkg/requestid"
+	"arvanch/pkg/security"
 	"arvanch/repository"
 	"arvanch/request"
 
@@ -18,146 +23,213 @@ import (
 )
 
 const (
-	xUserIDHeader = "X-USER-ID"
-
 	SmsPrice = 100
+
+	// ErrCodeRegionNotAllowed is returned when the recipient's region is not white listed.
+	ErrCodeRegionNotAllowed = "region_not_allowed"
+	// ErrCodeRecipientNotWhitelisted is returned when a sandbox account sends to a recipient out of the white list.
+	ErrCodeRecipientNotWhitelisted = "recipient_not_whitelisted"
+	// ErrCodeLocaleNotAllowed is returned when the requested locale is not allowed in the recipient's region.
+	ErrCodeLocaleNotAllowed = "locale_not_allowed"
 )
 
 type (
 	SMSHandler struct {
-		msgRepo      repository.MessageRepository
-		Region       i18n.Region
-		AccessLogger *access.Logger
-		reqValidator *validator.Validate
+		msgRepo         repository.MessageRepository
+		suppressionRepo repository.SuppressionRepository
+		Region          i18n.Region
+		reqValidator    *validator.Validate
+		recipientHMAC   *security.HMACKeyring
+		regionWhiteList []string
+		// maskRecipient masks recipients of messages returned to users.
+		maskRecipient bool
 	}
 )
 
 func NewSMSHandler(
 	msgRepo repository.MessageRepository,
+	suppressionRepo repository.SuppressionRepository,
 	region i18n.Region,
-	accessLogger *access.Logger,
 	reqValidator *validator.Validate,
+	recipientHMAC *security.HMACKeyring,
+	regionWhiteList []string,
+	maskRecipient bool,
 ) SMSHandler {
 	return SMSHandler{
-		msgRepo:      msgRepo,
-		Region:       region,
-		AccessLogger: accessLogger,
-		reqValidator: reqValidator,
+		msgRepo:         msgRepo,
+		suppressionRepo: suppressionRepo,
+		Region:          region,
+		reqValidator:    reqValidator,
+		recipientHMAC:   recipientHMAC,
+		regionWhiteList: regionWhiteList,
+		maskRecipient:   maskRecipient,
 	}
 }
 
 // nolint:funlen,gocognit,gocyclo
-func (s SMSHandler) Sms(c echo.Context) error {
-	smsLog := s.setupSMSLog(c)
+func (s SMSHandler) Sms(c echo.Context) (err error) {
+	entry := access.EntryFrom(c)
+
+	var region i18n.Region
 
 	defer func() {
-		if s.AccessLogger != nil {
-			s.AccessLogger.LogSMS(smsLog)
+		// returned errors are written after the handler returns.
+		status := c.Response().Status
+		if err != nil {
+			status = toAPIError(err).Status
 		}
-	}()
 
-	// Get the userID header
-	userID := c.Request().Header.Get(xUserIDHeader)
+		metrics.reportSMS(status, region.String(), provider(region), entry.Language)
+	}()
 
-	if userID == "" {
-		return c.String(http.StatusBadRequest, fmt.Sprintf("Missing %v header", xUserIDHeader))
+	userID, err := userIDFrom(c)
+	if err != nil {
+		return err
 	}
 
 	msgID := uuid.New().String()
-	smsLog.UUID = msgID
+	entry.UUID = msgID
 
 	var req request.SMS
-	req.Locale = locale.Default
 
 	if err := c.Bind(&req); err != nil {
-		smsLog.Payload = request.MarshalRawRequest(req)
-		smsLog.Error = fmt.Sprintf("sms handler: parsing body failed: %s", err.Error())
+		entry.Payload = request.MarshalRawRequest(req)
+		entry.Error = fmt.Sprintf("sms handler: parsing body failed: %s", err.Error())
 
-		return c.JSON(http.StatusBadRequest, echo.Map{"message": errors.New("request's body is not valid")})
+		return errInvalidBody.WithErr(err)
 	}
 
-	smsLog.Payload = request.MarshalRawRequest(req)
+	entry.Payload = request.MarshalRawRequest(req)
 
-	if err := req.Validate(s.reqValidator); err != nil {
-		smsLog.Error = fmt.Sprintf("sms handler: validation failed: %s", err.Error())
+	if err := req.Validate(s.reqValidator, s.regionWhiteList); err != nil {
+		entry.Error = fmt.Sprintf("sms handler: validation failed: %s", err.Error())
+
+		log.FromContext(c.Request().Context()).Debugf("sms handler: validation failed: %s", err.Error())
 
-		fmt.Println(err)
+		return err
+	}
+
+	recipient, err := i18n.Normalize(req.PhoneNumber, s.Region)
+	if err != nil {
+		entry.Error = fmt.Sprintf("sms handler: normalizing recipient failed: %s", err.Error())
 
-		return c.JSON(http.StatusBadRequest, echo.Map{"message": err})
+		return err
 	}
 
-	fmt.Println("hello")	
+	entry.Recipient = recipient.E164
+	region = recipient.Region
 
-	smsLog.Recipient = req.PhoneNumber
+	language, err := resolveLocale(req, recipient)
+	if err != nil {
+		entry.Error = fmt.Sprintf("sms handler: locale validation failed: %s", err.Error())
+
+		return NewAPIError(http.StatusBadRequest, ErrCodeLocaleNotAllowed,
+			fmt.Sprintf("locale %s is not allowed in region %s", req.Locale, recipient.Region))
+	}
+
+	entry.Language = language.String()
 
 	// read from cache
-	userProfile, err := s.msgRepo.GetUserProfile(userID)
+	userProfile, err := findProfile(c.Request().Context(), s.msgRepo, userID)
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, echo.Map{"message": err.Error()})
+		return err
 	}
 
-	err = s.msgRepo.InsertMessage(&model.Message{
-		ID:       msgID,
-		UserID:   userID,
-		Payload:  req.Payload,
-		Language: string(req.Locale),
-	})
+	if userProfile.Sandbox {
+		whitelisted, err := s.msgRepo.IsWhitelisted(c.Request().Context(), userProfile.AccountID, recipient.E164)
+		if err != nil {
+			return err
+		}
 
-	// TODO : use more specific errors
+		if !whitelisted {
+			entry.Error = "sms handler: recipient is not white listed for sandbox account"
+
+			return NewAPIError(http.StatusForbidden, ErrCodeRecipientNotWhitelisted,
+				"account is in sandbox mode and can only send to white listed recipients")
+		}
+	}
+
+	recipientHMACs, err := RecipientHMACs(s.recipientHMAC, recipient)
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, echo.Map{"message": err.Error()})
+		return err
+	}
+
+	suppressed, err := s.suppressionRepo.IsSuppressed(c.Request().Context(), userProfile.AccountID, recipientHMACs)
+	if err != nil {
+		return err
+	}
+
+	if suppressed {
+		entry.Error = "sms handler: recipient is suppressed"
+
+		return NewAPIError(http.StatusUnprocessableEntity, ErrCodeRecipientSuppressed,
+			"recipient has opted out of receiving messages")
 	}
 
-	// TODO : use more specific errors
-	err = s.msgRepo.IncrementAccountBalance(userProfile.AccountID, -SmsPrice)
+	// the message is stored only when its price is debited.
+	err = s.msgRepo.InsertChargedMessage(c.Request().Context(), userProfile.AccountID, &model.Message{
+		ID:              msgID,
+		UserID:          userID,
+		Recipient:       recipient.E164,
+		SenderID:        req.SenderID,
+		Payload:         req.Payload,
+		Language:        language.String(),
+		Status:          model.MessageStatusAccepted,
+		Segments:        i18n.Segments(req.Payload),
+		Price:           SmsPrice,
+		Provider:        provider(region),
+		ClientReference: req.ClientReference,
+		Metadata:        req.Metadata,
+		RequestID:       requestid.FromContext(c.Request().Context()),
+	})
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, echo.Map{"message": err.Error()})
+		return err
 	}
 
+	metrics.BalanceDebit.Add(SmsPrice)
+
 	return c.NoContent(http.StatusCreated)
 }
 
 // nolint:funlen,gocognit,gocyclo
 func (s SMSHandler) ChargeAccount(c echo.Context) error {
-	smsLog := s.setupSMSLog(c)
+	entry := access.EntryFrom(c)
 
-	defer func() {
-		if s.AccessLogger != nil {
-			s.AccessLogger.LogSMS(smsLog)
-		}
-	}()
-
-	// Get the userID header
-	userID := c.Request().Header.Get(xUserIDHeader)
-
-	if userID == "" {
-		return c.String(http.StatusBadRequest, fmt.Sprintf("Missing %v header", xUserIDHeader))
+	userID, err := userIDFrom(c)
+	if err != nil {
+		return err
 	}
 
 	var req request.Charge
 	if err := c.Bind(&req); err != nil {
-		smsLog.Payload = request.MarshalRawRequest(req)
-		smsLog.Error = fmt.Sprintf("sms handler: parsing body failed: %s", err.Error())
+		entry.Payload = request.MarshalRawRequest(req)
+		entry.Error = fmt.Sprintf("sms handler: parsing body failed: %s", err.Error())
 
-		return c.JSON(http.StatusBadRequest, echo.Map{"message": err})
+		return errInvalidBody.WithErr(err)
 	}
 
-	smsLog.Payload = request.MarshalRawRequest(req)
+	entry.Payload = request.MarshalRawRequest(req)
 
 	if err := req.Validate(s.reqValidator); err != nil {
-		smsLog.Error = fmt.Sprintf("sms handler: validation failed: %s", err.Error())
+		entry.Error = fmt.Sprintf("sms handler: validation failed: %s", err.Error())
 
-		return c.JSON(http.StatusBadRequest, echo.Map{"message": err})
+		return err
 	}
 
-	userProfile, err := s.msgRepo.GetUserProfile(userID)
+	userProfile, err := findProfile(c.Request().Context(), s.msgRepo, userID)
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, echo.Map{"message": err.Error()})
+		return err
 	}
 
-	err = s.msgRepo.IncrementAccountBalance(userProfile.AccountID, req.Amount)
+	err = s.msgRepo.IncrementAccountBalance(c.Request().Context(), userProfile.AccountID, req.Amount)
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, echo.Map{"message": err.Error()})
+		return err
+	}
+
+	if req.Amount > 0 {
+		metrics.BalanceCredit.Add(float64(req.Amount))
+	} else {
+		metrics.BalanceDebit.Add(float64(-req.Amount))
 	}
 
 	return c.NoContent(http.StatusOK)
@@ -165,61 +237,75 @@ func (s SMSHandler) ChargeAccount(c echo.Context) error {
 
 // nolint:funlen,gocognit,gocyclo
 func (s SMSHandler) GetUserMessages(c echo.Context) error {
-	smsLog := s.setupSMSLog(c)
+	userID, err := userIDFrom(c)
+	if err != nil {
+		return err
+	}
 
-	defer func() {
-		if s.AccessLogger != nil {
-			s.AccessLogger.LogSMS(smsLog)
-		}
-	}()
+	var req request.Messages
+	if err := c.Bind(&req); err != nil {
+		return errInvalidQuery.WithErr(err)
+	}
 
-	// Get the userID header
-	userID := c.Request().Header.Get(xUserIDHeader)
+	if err := req.Validate(s.reqValidator); err != nil {
+		return err
+	}
 
-	if userID == "" {
-		return c.String(http.StatusBadRequest, fmt.Sprintf("Missing %v header", xUserIDHeader))
+	filter, err := messageFilter(req, s.Region)
+	if err != nil {
+		return err
 	}
 
-	fmt.Println("hello")
+	limit := req.PageLimit()
+	// fetch one more message to know whether there is a next page.
+	filter.Limit = limit + 1
 
-	msgs, err := s.msgRepo.GetUserMessages(userID)
+	// unknown users are not found rather than having no messages.
+	if _, err := findProfile(c.Request().Context(), s.msgRepo, userID); err != nil {
+		return err
+	}
+
+	msgs, err := s.msgRepo.GetUserMessages(c.Request().Context(), userID, filter)
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, echo.Map{"message": err.Error()})
+		return err
 	}
 
-	return c.JSON(http.StatusOK, msgs)
+	msgs, nextCursor := paginate(msgs, limit)
+
+	if s.maskRecipient {
+		for i := range msgs {
+			msgs[i].Recipient = mask.Phone(msgs[i].Recipient)
+		}
+	}
+
+	return c.JSON(http.StatusOK, echo.Map{"messages": msgs, "next_cursor": nextCursor})
 }
 
 // nolint:funlen,gocognit,gocyclo
 func (s SMSHandler) CreateAccount(c echo.Context) error {
-	smsLog := s.setupSMSLog(c)
-
-	defer func() {
-		if s.AccessLogger != nil {
-			s.AccessLogger.LogSMS(smsLog)
-		}
-	}()
+	entry := access.EntryFrom(c)
 
 	var req request.Account
 	if err := c.Bind(&req); err != nil {
-		smsLog.Payload = request.MarshalRawRequest(req)
-		smsLog.Error = fmt.Sprintf("sms handler: parsing body failed: %s", err.Error())
+		entry.Payload = request.MarshalRawRequest(req)
+		entry.Error = fmt.Sprintf("sms handler: parsing body failed: %s", err.Error())
 
-		return c.JSON(http.StatusBadRequest, echo.Map{"message": err})
+		return errInvalidBody.WithErr(err)
 	}
 
-	smsLog.Payload = request.MarshalRawRequest(req)
+	entry.Payload = request.MarshalRawRequest(req)
 
 	if err := req.Validate(s.reqValidator); err != nil {
-		smsLog.Error = fmt.Sprintf("sms handler: validation failed: %s", err.Error())
+		entry.Error = fmt.Sprintf("sms handler: validation failed: %s", err.Error())
 
-		return c.JSON(http.StatusBadRequest, echo.Map{"message": err})
+		return err
 	}
 
 	userID := uuid.New().String()
+	entry.UserID = userID
 
-	if err := s.msgRepo.InsertUserWithAccount(userID, req.Name); err != nil {
-		return c.JSON(http.StatusInternalServerError, echo.Map{"message": err.Error()})
+	if err := s.msgRepo.InsertUserWithAccount(c.Request().Context(), userID, req.Name); err != nil {
+		return err
 	}
 
 	return c.JSON(http.StatusCreated, &model.User{
@@ -229,35 +315,197 @@ func (s SMSHandler) CreateAccount(c echo.Context) error {
 
 // nolint:funlen,gocognit,gocyclo
 func (s SMSHandler) GetProfile(c echo.Context) error {
-	smsLog := s.setupSMSLog(c)
+	userID, err := userIDFrom(c)
+	if err != nil {
+		return err
+	}
 
-	defer func() {
-		if s.AccessLogger != nil {
-			s.AccessLogger.LogSMS(smsLog)
+	log.FromContext(c.Request().Context()).Debug("sms handler: profile requested")
+
+	profile, err := findProfile(c.Request().Context(), s.msgRepo, userID)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, profile)
+}
+
+// SetSandbox enables or disables the sandbox mode of an account.
+func (s SMSHandler) SetSandbox(c echo.Context) error {
+	var req request.Sandbox
+	if err := c.Bind(&req); err != nil {
+		return errInvalidBody.WithErr(err)
+	}
+
+	if err := req.Validate(s.reqValidator); err != nil {
+		return err
+	}
+
+	err := s.msgRepo.SetAccountSandbox(c.Request().Context(), c.Param("id"), *req.Enabled)
+	if errors.Is(err, model.ErrRecordNotFound) {
+		return NewAPIError(http.StatusNotFound, ErrCodeNotFound, "account not found")
+	}
+
+	if err != nil {
+		return err
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// SetWhitelist replaces the recipients an account can send to in sandbox mode.
+func (s SMSHandler) SetWhitelist(c echo.Context) error {
+	entry := access.EntryFrom(c)
+
+	var req request.Whitelist
+	if err := c.Bind(&req); err != nil {
+		return errInvalidBody.WithErr(err)
+	}
+
+	entry.Payload = request.MarshalRawRequest(req)
+
+	if err := req.Validate(s.reqValidator); err != nil {
+		entry.Error = fmt.Sprintf("sms handler: validation failed: %s", err.Error())
+
+		return err
+	}
+
+	// recipients are stored in E.164 format, the format they are checked in when sending.
+	recipients := make([]string, 0, len(req.PhoneNumbers))
+	seen := make(map[string]bool, len(req.PhoneNumbers))
+
+	for _, number := range req.PhoneNumbers {
+		recipient, err := i18n.Normalize(number, s.Region)
+		if err != nil {
+			return err
 		}
-	}()
 
-	// Get the userID header
-	userID := c.Request().Header.Get(xUserIDHeader)
+		if !seen[recipient.E164] {
+			seen[recipient.E164] = true
+			recipients = append(recipients, recipient.E164)
+		}
+	}
 
-	if userID == "" {
-		return c.String(http.StatusBadRequest, fmt.Sprintf("Missing %v header", xUserIDHeader))
+	err := s.msgRepo.SetWhitelistedNumbers(c.Request().Context(), c.Param("id"), recipients)
+	if errors.Is(err, model.ErrRecordNotFound) {
+		return NewAPIError(http.StatusNotFound, ErrCodeNotFound, "account not found")
+	}
+
+	if err != nil {
+		return err
 	}
 
-	fmt.Println("user ID : ", userID)
+	return c.NoContent(http.StatusNoContent)
+}
 
-	profile, err := s.msgRepo.GetUserProfile(userID)
+// GetWhitelist returns the recipients an account can send to in sandbox mode.
+func (s SMSHandler) GetWhitelist(c echo.Context) error {
+	recipients, err := s.msgRepo.GetWhitelistedNumbers(c.Request().Context(), c.Param("id"))
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, echo.Map{"message": err.Error()})
+		return err
 	}
 
-	return c.JSON(http.StatusOK, profile)
+	if recipients == nil {
+		recipients = []string{}
+	}
+
+	return c.JSON(http.StatusOK, echo.Map{"phone_numbers": recipients})
 }
 
-func (s SMSHandler) setupSMSLog(c echo.Context) *access.SMSLog {
-	return &access.SMSLog{
-		XForwardedFor: c.Request().Header.Get(echo.HeaderXForwardedFor),
-		XRealIP:       c.Request().Header.Get(echo.HeaderXRealIP),
-		RemoteAddress: c.Request().RemoteAddr,
+// provider returns the default provider of a region.
+func provider(region i18n.Region) string {
+	if info, ok := i18n.Lookup(region); ok {
+		return info.DefaultProvider
 	}
+
+	return ""
+}
+
+// resolveLocale returns the requested locale when it is allowed in the recipient's region,
+// or detects it from the payload's script when no locale is requested. Detected locales which
+// are not allowed in the region are not rejected, as the client did not ask for them; they are Default.
+func resolveLocale(req request.SMS, recipient i18n.PhoneNumber) (locale.Locale, error) {
+	if req.Locale == "" {
+		detected := locale.Detect(req.Payload)
+		if err := locale.ValidateRegion(detected, recipient.Region); err != nil {
+			return locale.Default, nil
+		}
+
+		return detected, nil
+	}
+
+	if err := locale.ValidateRegion(req.Locale, recipient.Region); err != nil {
+		return "", err
+	}
+
+	return req.Locale, nil
+}
+
+// messageFilter converts the query of listing messages to a repository filter.
+func messageFilter(req request.Messages, region i18n.Region) (repository.MessageFilter, error) {
+	filter := repository.MessageFilter{
+		Status:    req.Status,
+		Language:  req.Locale,
+		From:      req.From,
+		To:        req.To,
+		Ascending: req.Sort == "asc",
+	}
+
+	if req.Recipient != "" {
+		recipient, err := i18n.Normalize(req.Recipient, region)
+		if err != nil {
+			return filter, err
+		}
+
+		filter.Recipient = recipient.E164
+	}
+
+	if req.Cursor != "" {
+		cursor, err := repository.ParseMessageCursor(req.Cursor)
+		if err != nil {
+			return filter, err
+		}
+
+		filter.After = cursor
+	}
+
+	return filter, nil
+}
+
+// paginate trims the messages fetched with one extra to the page limit
+// and returns the cursor of the next page, which is empty on the last page.
+func paginate(msgs []model.Message, limit int) ([]model.Message, string) {
+	if len(msgs) <= limit {
+		return msgs, ""
+	}
+
+	msgs = msgs[:limit]
+	last := msgs[limit-1]
+
+	return msgs, repository.MessageCursor{CreatedAt: last.CreatedAt, ID: last.ID}.String()
+}
+
+// userIDFrom returns the ID of the requesting user, which must be a UUID.
+func userIDFrom(c echo.Context) (string, error) {
+	userID := c.Request().Header.Get(log.UserIDHeader)
+
+	if userID == "" {
+		return "", errMissingUserID
+	}
+
+	if _, err := uuid.Parse(userID); err != nil {
+		return "", errInvalidUserID.WithErr(err)
+	}
+
+	return userID, nil
+}
+
+// findProfile returns the profile of the user, or errUserNotFound when there is no such user.
+func findProfile(ctx context.Context, msgRepo repository.MessageRepository, userID string) (model.Profile, error) {
+	profile, err := msgRepo.GetUserProfile(ctx, userID)
+	if errors.Is(err, model.ErrRecordNotFound) {
+		return profile, errUserNotFound.WithErr(err)
+	}
+
+	return profile, err
 }
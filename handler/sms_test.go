@@ -2,20 +2,26 @@ package handler
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 
 	"arvanch/config"
 	"arvanch/db"
 	"arvanch/i18n"
+	"arvanch/log"
+	"arvanch/model"
 	"arvanch/pkg/locale"
+	"arvanch/pkg/security"
 	"arvanch/repository"
 	"arvanch/request"
 
 	"github.com/go-playground/validator/v10"
 	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/require"
 	"github.com/stretchr/testify/suite"
 )
 
@@ -31,6 +37,7 @@ type SMSTestSuite struct {
 
 func (suite *SMSTestSuite) SetupSuite() {
 	suite.engine = echo.New()
+	suite.engine.HTTPErrorHandler = HTTPErrorHandler
 
 	g := suite.engine.Group("api")
 
@@ -46,15 +53,32 @@ func (suite *SMSTestSuite) SetupSuite() {
 	suite.NoError(err)
 
 	// TODO : should be implemented with mock repo
-	repo := repository.NewMessageRepo(db.WithRetry(db.Create, config.Init().Postgres))
+	database, err := db.WithRetry(context.Background(), db.Create, config.Init().Postgres)
+	suite.Require().NoError(err)
 
-	repo.InsertUserWithAccount(DefaultUserID, "test")
+	cipher, err := repository.NewMessageCipher(config.Init().Encryption)
+	suite.Require().NoError(err)
 
-	prof, err := repo.GetUserProfile(DefaultUserID)
+	repo := repository.NewMessageRepo(db.NewResolver(database, nil, 0), cipher)
 
-	repo.IncrementAccountBalance(prof.AccountID, 10000000)
+	repo.InsertUserWithAccount(context.Background(), DefaultUserID, "test")
 
-	g.POST("/sms/phone", NewSMSHandler(repo, i18n.Arvan, nil, suite.reqValidator).Sms)
+	prof, err := repo.GetUserProfile(context.Background(), DefaultUserID)
+
+	repo.IncrementAccountBalance(context.Background(), prof.AccountID, 10000000)
+
+	recipientHMAC, err := security.NewHMACKeyring(config.Keyring{Active: "1", Keys: map[string]string{"1": "mac"}})
+	suite.Require().NoError(err)
+
+	g.POST("/sms/phone", NewSMSHandler(
+		repo,
+		repository.NewSuppressionRepo(database),
+		i18n.Arvan,
+		suite.reqValidator,
+		recipientHMAC,
+		[]string{"arvan"},
+		true,
+	).Sms)
 }
 
 // nolint:funlen,gocognit
@@ -178,3 +202,364 @@ func (suite *SMSTestSuite) TestSMSHandler() {
 func TestSMS(t *testing.T) {
 	suite.Run(t, new(SMSTestSuite))
 }
+
+func TestResolveLocale(t *testing.T) {
+	t.Parallel()
+
+	iranian := i18n.PhoneNumber{E164: "+989121234567", Region: i18n.Arvan}
+	iraqi := i18n.PhoneNumber{E164: "+9647773041055", Region: i18n.Region("iraq")}
+	turkish := i18n.PhoneNumber{E164: "+905321234567", Region: i18n.Region("turkey")}
+
+	cases := []struct {
+		name      string
+		req       request.SMS
+		recipient i18n.PhoneNumber
+		expected  locale.Locale
+		wantErr   bool
+	}{
+		{
+			name:      "requested locale",
+			req:       request.SMS{Payload: "Hello", Locale: locale.EN},
+			recipient: iranian,
+			expected:  locale.EN,
+		},
+		{
+			name:      "detected persian",
+			req:       request.SMS{Payload: "سلام"},
+			recipient: turkish,
+			expected:  locale.FA,
+		},
+		{
+			name:      "detected locale not allowed in region",
+			req:       request.SMS{Payload: "سلام"},
+			recipient: iranian,
+			expected:  locale.Default,
+		},
+		{
+			name:      "detected kurdish",
+			req:       request.SMS{Payload: "سڵاو"},
+			recipient: iraqi,
+			expected:  locale.KU,
+		},
+		{
+			name:      "locale not allowed in region",
+			req:       request.SMS{Payload: "سلام", Locale: locale.FA},
+			recipient: iraqi,
+			wantErr:   true,
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			resolved, err := resolveLocale(tt.req, tt.recipient)
+
+			require.Equal(t, tt.wantErr, err != nil)
+			require.Equal(t, tt.expected, resolved)
+		})
+	}
+}
+
+// memoryRepo is an in-memory message repository of users, their accounts, messages and white lists.
+type memoryRepo struct {
+	repository.MessageRepository
+	profiles   map[string]model.Profile
+	messages   []model.Message
+	whitelists map[string][]string
+}
+
+func newMemoryRepo(userIDs ...string) *memoryRepo {
+	repo := &memoryRepo{profiles: map[string]model.Profile{}, whitelists: map[string][]string{}}
+
+	for _, id := range userIDs {
+		repo.profiles[id] = model.Profile{
+			User:    model.User{ID: id, AccountID: "account-" + id},
+			Account: model.Account{ID: "account-" + id, Balance: 1000},
+		}
+	}
+
+	return repo
+}
+
+func (m *memoryRepo) GetUserProfile(_ context.Context, userID string) (model.Profile, error) {
+	profile, ok := m.profiles[userID]
+	if !ok {
+		return model.Profile{}, model.ErrRecordNotFound
+	}
+
+	return profile, nil
+}
+
+func (m *memoryRepo) GetUserMessages(_ context.Context, userID string, _ repository.MessageFilter) ([]model.Message, error) {
+	var msgs []model.Message
+
+	for _, msg := range m.messages {
+		if msg.UserID == userID {
+			msgs = append(msgs, msg)
+		}
+	}
+
+	return msgs, nil
+}
+
+func (m *memoryRepo) InsertChargedMessage(ctx context.Context, accountID string, msg *model.Message) error {
+	if err := m.IncrementAccountBalance(ctx, accountID, -msg.Price); err != nil {
+		return err
+	}
+
+	m.messages = append(m.messages, *msg)
+
+	return nil
+}
+
+func (m *memoryRepo) IncrementAccountBalance(_ context.Context, accountID string, amount int64) error {
+	for id, profile := range m.profiles {
+		if profile.User.AccountID == accountID {
+			if profile.Balance+amount < 0 {
+				return model.ErrInsufficientBalance
+			}
+
+			profile.Balance += amount
+			m.profiles[id] = profile
+
+			return nil
+		}
+	}
+
+	return model.ErrRecordNotFound
+}
+
+func (m *memoryRepo) SetWhitelistedNumbers(_ context.Context, accountID string, recipients []string) error {
+	m.whitelists[accountID] = recipients
+
+	return nil
+}
+
+func (m *memoryRepo) IsWhitelisted(_ context.Context, accountID, recipient string) (bool, error) {
+	for _, number := range m.whitelists[accountID] {
+		if number == recipient {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// memorySuppressionRepo is an empty opt-out list.
+type memorySuppressionRepo struct {
+	repository.SuppressionRepository
+}
+
+func (memorySuppressionRepo) IsSuppressed(context.Context, string, []string) (bool, error) {
+	return false, nil
+}
+
+func TestGetUserMessagesMasked(t *testing.T) {
+	t.Parallel()
+
+	reqValidator, err := request.NewValidator()
+	require.NoError(t, err)
+
+	repo := newMemoryRepo(DefaultUserID)
+	repo.messages = []model.Message{{ID: "1", UserID: DefaultUserID, Recipient: "+989121234567"}}
+
+	for _, maskRecipient := range []bool{true, false} {
+		h := NewSMSHandler(repo, nil, i18n.Arvan, reqValidator, nil, nil, maskRecipient)
+
+		req := httptest.NewRequest(http.MethodGet, "/messages", nil)
+		req.Header.Set(log.UserIDHeader, DefaultUserID)
+
+		w := httptest.NewRecorder()
+		require.NoError(t, h.GetUserMessages(echo.New().NewContext(req, w)))
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var resp struct {
+			Messages []model.Message `json:"messages"`
+		}
+
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+
+		expected := "+989121234567"
+		if maskRecipient {
+			expected = "+98912***4567"
+		}
+
+		require.Equal(t, expected, resp.Messages[0].Recipient)
+	}
+}
+
+// nolint:funlen
+func TestUnknownUsers(t *testing.T) {
+	t.Parallel()
+
+	reqValidator, err := request.NewValidator()
+	require.NoError(t, err)
+
+	recipientHMAC, err := security.NewHMACKeyring(config.Keyring{Active: "1", Keys: map[string]string{"1": "mac"}})
+	require.NoError(t, err)
+
+	repo := newMemoryRepo(DefaultUserID)
+
+	h := NewSMSHandler(repo, memorySuppressionRepo{}, i18n.Arvan, reqValidator, recipientHMAC,
+		[]string{i18n.Arvan.String()}, false)
+
+	e := echo.New()
+	e.HTTPErrorHandler = HTTPErrorHandler
+	e.POST("/sms", h.Sms)
+	e.POST("/charge", h.ChargeAccount)
+	e.GET("/messages", h.GetUserMessages)
+	e.GET("/profile", h.GetProfile)
+
+	endpoints := []struct {
+		method string
+		path   string
+		body   string
+		status int
+	}{
+		{method: http.MethodPost, path: "/sms", body: `{"phone_number": "09121234567", "payload": "Hi"}`, status: http.StatusCreated},
+		{method: http.MethodPost, path: "/charge", body: `{"amount": 100}`, status: http.StatusOK},
+		{method: http.MethodGet, path: "/messages", status: http.StatusOK},
+		{method: http.MethodGet, path: "/profile", status: http.StatusOK},
+	}
+
+	users := []struct {
+		name   string
+		userID string
+		status int
+		code   string
+	}{
+		{name: "missing", userID: "", status: http.StatusUnauthorized, code: ErrCodeMissingHeader},
+		{name: "malformed", userID: "1 or 1=1", status: http.StatusUnauthorized, code: ErrCodeUnauthorized},
+		{name: "unknown", userID: "0b7e2f4a-1c3d-4e5f-8a9b-6c7d8e9f0a1b", status: http.StatusNotFound, code: ErrCodeNotFound},
+		{name: "known", userID: DefaultUserID},
+	}
+
+	for _, ep := range endpoints {
+		for _, user := range users {
+			req := httptest.NewRequest(ep.method, ep.path, strings.NewReader(ep.body))
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+
+			if user.userID != "" {
+				req.Header.Set(log.UserIDHeader, user.userID)
+			}
+
+			w := httptest.NewRecorder()
+			e.ServeHTTP(w, req)
+
+			if user.status == 0 {
+				require.Equal(t, ep.status, w.Code, "%s %s by %s user", ep.method, ep.path, user.name)
+
+				continue
+			}
+
+			require.Equal(t, user.status, w.Code, "%s %s by %s user", ep.method, ep.path, user.name)
+
+			var body APIError
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+			require.Equal(t, user.code, body.Code, "%s %s by %s user", ep.method, ep.path, user.name)
+		}
+	}
+
+	require.Len(t, repo.messages, 1, "only the known user's message should be inserted")
+	require.Equal(t, DefaultUserID, repo.messages[0].UserID)
+	require.EqualValues(t, 1000, repo.profiles[DefaultUserID].Balance, "the charge should cover the sms price")
+}
+
+func TestSandboxWhitelist(t *testing.T) {
+	t.Parallel()
+
+	const otherUserID = "5d1c8e2a-7b3f-4a6e-9c0d-1e2f3a4b5c6d"
+
+	reqValidator, err := request.NewValidator()
+	require.NoError(t, err)
+
+	recipientHMAC, err := security.NewHMACKeyring(config.Keyring{Active: "1", Keys: map[string]string{"1": "mac"}})
+	require.NoError(t, err)
+
+	repo := newMemoryRepo(DefaultUserID, otherUserID)
+
+	for id, profile := range repo.profiles {
+		profile.Sandbox = true
+		repo.profiles[id] = profile
+	}
+
+	h := NewSMSHandler(repo, memorySuppressionRepo{}, i18n.Arvan, reqValidator, recipientHMAC,
+		[]string{i18n.Arvan.String()}, false)
+
+	e := echo.New()
+	e.HTTPErrorHandler = HTTPErrorHandler
+	e.POST("/sms", h.Sms)
+	e.PUT("/accounts/:id/whitelist", h.SetWhitelist)
+
+	req := httptest.NewRequest(http.MethodPut, "/accounts/account-"+DefaultUserID+"/whitelist",
+		strings.NewReader(`{"phone_numbers": ["09121234567", "+989121234567"]}`))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+
+	w := httptest.NewRecorder()
+	e.ServeHTTP(w, req)
+	require.Equal(t, http.StatusNoContent, w.Code)
+	require.Equal(t, []string{"+989121234567"}, repo.whitelists["account-"+DefaultUserID])
+
+	cases := []struct {
+		name      string
+		userID    string
+		recipient string
+		status    int
+	}{
+		{name: "white listed recipient", userID: DefaultUserID, recipient: "09121234567", status: http.StatusCreated},
+		{name: "other recipient", userID: DefaultUserID, recipient: "09127654321", status: http.StatusForbidden},
+		{name: "recipient of another account", userID: otherUserID, recipient: "09121234567", status: http.StatusForbidden},
+	}
+
+	for _, tc := range cases {
+		body := `{"phone_number": "` + tc.recipient + `", "payload": "Hi"}`
+
+		req := httptest.NewRequest(http.MethodPost, "/sms", strings.NewReader(body))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		req.Header.Set(log.UserIDHeader, tc.userID)
+
+		w := httptest.NewRecorder()
+		e.ServeHTTP(w, req)
+		require.Equal(t, tc.status, w.Code, tc.name)
+	}
+}
+
+func TestSMSInsufficientBalance(t *testing.T) {
+	t.Parallel()
+
+	reqValidator, err := request.NewValidator()
+	require.NoError(t, err)
+
+	recipientHMAC, err := security.NewHMACKeyring(config.Keyring{Active: "1", Keys: map[string]string{"1": "mac"}})
+	require.NoError(t, err)
+
+	repo := newMemoryRepo(DefaultUserID)
+
+	profile := repo.profiles[DefaultUserID]
+	profile.Balance = 0
+	repo.profiles[DefaultUserID] = profile
+
+	h := NewSMSHandler(repo, memorySuppressionRepo{}, i18n.Arvan, reqValidator, recipientHMAC,
+		[]string{i18n.Arvan.String()}, false)
+
+	e := echo.New()
+	e.HTTPErrorHandler = HTTPErrorHandler
+	e.POST("/sms", h.Sms)
+
+	req := httptest.NewRequest(http.MethodPost, "/sms", strings.NewReader(`{"phone_number": "09121234567", "payload": "Hi"}`))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	req.Header.Set(log.UserIDHeader, DefaultUserID)
+
+	w := httptest.NewRecorder()
+	e.ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusPaymentRequired, w.Code)
+
+	var body APIError
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	require.Equal(t, ErrCodeInsufficientBalance, body.Code)
+
+	require.Empty(t, repo.messages, "no message should be stored without debiting its price")
+	require.Zero(t, repo.profiles[DefaultUserID].Balance)
+}
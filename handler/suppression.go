@@ -0,0 +1,259 @@
+package handler
+
+import (
+	"net/http"
+	"strings"
+
+	"arvanch/i18n"
+	"arvanch/log"
+	"arvanch/model"
+	"arvanch/pkg/security"
+	"arvanch/repository"
+	"arvanch/request"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+// ErrCodeRecipientSuppressed is returned when the recipient is on the opt-out list.
+const ErrCodeRecipientSuppressed = "recipient_suppressed"
+
+type SuppressionHandler struct {
+	msgRepo         repository.MessageRepository
+	suppressionRepo repository.SuppressionRepository
+	Region          i18n.Region
+	recipientHMAC   *security.HMACKeyring
+	keywords        []string
+	reqValidator    *validator.Validate
+}
+
+func NewSuppressionHandler(
+	msgRepo repository.MessageRepository,
+	suppressionRepo repository.SuppressionRepository,
+	region i18n.Region,
+	recipientHMAC *security.HMACKeyring,
+	keywords []string,
+	reqValidator *validator.Validate,
+) SuppressionHandler {
+	return SuppressionHandler{
+		msgRepo:         msgRepo,
+		suppressionRepo: suppressionRepo,
+		Region:          region,
+		recipientHMAC:   recipientHMAC,
+		keywords:        keywords,
+		reqValidator:    reqValidator,
+	}
+}
+
+// AddAccount adds a recipient to the opt-out list of the user's account.
+func (s SuppressionHandler) AddAccount(c echo.Context) error {
+	accountID, err := s.accountID(c)
+	if err != nil {
+		return err
+	}
+
+	return s.add(c, accountID)
+}
+
+// RemoveAccount removes a recipient from the opt-out list of the user's account.
+func (s SuppressionHandler) RemoveAccount(c echo.Context) error {
+	accountID, err := s.accountID(c)
+	if err != nil {
+		return err
+	}
+
+	return s.remove(c, accountID)
+}
+
+// ListAccount lists the opt-out list of the user's account.
+func (s SuppressionHandler) ListAccount(c echo.Context) error {
+	accountID, err := s.accountID(c)
+	if err != nil {
+		return err
+	}
+
+	return s.list(c, accountID)
+}
+
+// AddGlobal adds a recipient to the global opt-out list.
+func (s SuppressionHandler) AddGlobal(c echo.Context) error {
+	return s.add(c, "")
+}
+
+// RemoveGlobal removes a recipient from the global opt-out list.
+func (s SuppressionHandler) RemoveGlobal(c echo.Context) error {
+	return s.remove(c, "")
+}
+
+// ListGlobal lists the global opt-out list.
+func (s SuppressionHandler) ListGlobal(c echo.Context) error {
+	return s.list(c, "")
+}
+
+// Inbound receives SMS sent by recipients and adds the sender to the global
+// opt-out list when the message is an opt-out keyword.
+func (s SuppressionHandler) Inbound(c echo.Context) error {
+	var req request.Inbound
+	if err := c.Bind(&req); err != nil {
+		return errInvalidBody.WithErr(err)
+	}
+
+	if err := req.Validate(s.reqValidator); err != nil {
+		return err
+	}
+
+	if !IsOptOutKeyword(s.keywords, req.Payload) {
+		return c.NoContent(http.StatusNoContent)
+	}
+
+	sender, err := i18n.Normalize(req.Sender, s.Region)
+	if err != nil {
+		return err
+	}
+
+	recipientHMAC, err := RecipientHMAC(s.recipientHMAC, sender)
+	if err != nil {
+		return err
+	}
+
+	recipientHMACs, err := RecipientHMACs(s.recipientHMAC, sender)
+	if err != nil {
+		return err
+	}
+
+	if err := s.suppressionRepo.InsertSuppression(c.Request().Context(), &model.Suppression{
+		ID:            uuid.New().String(),
+		RecipientHMAC: recipientHMAC,
+		Source:        model.SuppressionSourceInbound,
+	}, recipientHMACs); err != nil {
+		return err
+	}
+
+	log.FromContext(c.Request().Context()).Infof("recipient opted out by inbound keyword [recipient_hmac: %s]", recipientHMAC)
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+func (s SuppressionHandler) add(c echo.Context, accountID string) error {
+	recipient, err := s.bindRecipient(c)
+	if err != nil {
+		return err
+	}
+
+	recipientHMAC, err := RecipientHMAC(s.recipientHMAC, recipient)
+	if err != nil {
+		return err
+	}
+
+	recipientHMACs, err := RecipientHMACs(s.recipientHMAC, recipient)
+	if err != nil {
+		return err
+	}
+
+	sup := &model.Suppression{
+		ID:            uuid.New().String(),
+		RecipientHMAC: recipientHMAC,
+		Source:        model.SuppressionSourceAPI,
+	}
+
+	if accountID != "" {
+		sup.AccountID = &accountID
+	}
+
+	if err := s.suppressionRepo.InsertSuppression(c.Request().Context(), sup, recipientHMACs); err != nil {
+		return err
+	}
+
+	return c.NoContent(http.StatusCreated)
+}
+
+func (s SuppressionHandler) remove(c echo.Context, accountID string) error {
+	recipient, err := s.bindRecipient(c)
+	if err != nil {
+		return err
+	}
+
+	recipientHMACs, err := RecipientHMACs(s.recipientHMAC, recipient)
+	if err != nil {
+		return err
+	}
+
+	found, err := s.suppressionRepo.DeleteSuppression(c.Request().Context(), accountID, recipientHMACs)
+	if err != nil {
+		return err
+	}
+
+	if !found {
+		return NewAPIError(http.StatusNotFound, ErrCodeNotFound, "recipient is not suppressed")
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+func (s SuppressionHandler) list(c echo.Context, accountID string) error {
+	suppressions, err := s.suppressionRepo.GetSuppressions(c.Request().Context(), accountID)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, suppressions)
+}
+
+// bindRecipient parses and validates the request and returns the normalized recipient.
+func (s SuppressionHandler) bindRecipient(c echo.Context) (i18n.PhoneNumber, error) {
+	var req request.Suppression
+	if err := c.Bind(&req); err != nil {
+		return i18n.PhoneNumber{}, errInvalidBody.WithErr(err)
+	}
+
+	if err := req.Validate(s.reqValidator); err != nil {
+		return i18n.PhoneNumber{}, err
+	}
+
+	recipient, err := i18n.Normalize(req.PhoneNumber, s.Region)
+	if err != nil {
+		return i18n.PhoneNumber{}, err
+	}
+
+	return recipient, nil
+}
+
+// accountID returns the account of the requesting user.
+func (s SuppressionHandler) accountID(c echo.Context) (string, error) {
+	userID, err := userIDFrom(c)
+	if err != nil {
+		return "", err
+	}
+
+	userProfile, err := findProfile(c.Request().Context(), s.msgRepo, userID)
+	if err != nil {
+		return "", err
+	}
+
+	return userProfile.AccountID, nil
+}
+
+// RecipientHMAC returns the key of a recipient in the opt-out list.
+func RecipientHMAC(hmac *security.HMACKeyring, recipient i18n.PhoneNumber) (string, error) {
+	return hmac.Transform(recipient.E164)
+}
+
+// RecipientHMACs returns the keys a recipient may be stored with in the opt-out list,
+// one for each HMAC key, to look up entries added before a key rotation.
+func RecipientHMACs(hmac *security.HMACKeyring, recipient i18n.PhoneNumber) ([]string, error) {
+	return hmac.Candidates(recipient.E164)
+}
+
+// IsOptOutKeyword checks whether an inbound payload is one of the opt-out keywords.
+func IsOptOutKeyword(keywords []string, payload string) bool {
+	payload = strings.TrimSpace(payload)
+
+	for _, keyword := range keywords {
+		if strings.EqualFold(payload, keyword) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,52 @@
+package handler
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsOptOutKeyword(t *testing.T) {
+	t.Parallel()
+
+	keywords := []string{"STOP", "لغو"}
+
+	cases := []struct {
+		name     string
+		payload  string
+		expected bool
+	}{
+		{
+			name:     "english",
+			payload:  "STOP",
+			expected: true,
+		},
+		{
+			name:     "english lower case with spaces",
+			payload:  " stop\n",
+			expected: true,
+		},
+		{
+			name:     "persian",
+			payload:  "لغو",
+			expected: true,
+		},
+		{
+			name:     "keyword in a sentence",
+			payload:  "please stop",
+			expected: false,
+		},
+		{
+			name:     "empty",
+			payload:  "",
+			expected: false,
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			require.Equal(t, tt.expected, IsOptOutKeyword(keywords, tt.payload))
+		})
+	}
+}
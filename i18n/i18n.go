@@ -3,70 +3,72 @@ package i18n
 import (
 	"errors"
 	"regexp"
+	"strings"
 )
 
 type (
-	// Region of company.
-	Region uint64
-	// Regions array of Region.
-	Regions uint64
+	// Region of company, the name of a registered region.
+	Region string
+	// Regions set of Region.
+	Regions map[Region]struct{}
 )
 
 const (
 	// Invalid Region.
-	Invalid Region = 0
+	Invalid Region = ""
 	// Arvan type.
-	Arvan = 1 << iota
+	Arvan Region = "arvan"
 	// Turkey type.
-	Turkey
+	Turkey Region = "turkey"
 
 	// ArvanPhoneCode iran's calling code.
 	ArvanPhoneCode = "98"
 
 	PersianLanguage = "persian"
 	ArabicLanguage  = "arabic"
+	KurdishLanguage = "kurdish"
 	EnglishLanguage = "english"
 )
 
 // nolint: gochecknoglobals, godot
 var (
-	arabicAlphabet  = regexp.MustCompile(`^[؀-ۿ]+`)
+	arabicScript    = regexp.MustCompile(`[؀-ۿ]`)
 	persianAlphabet = regexp.MustCompile(`^[آ-۹]+`)
 
+	// letters which among the arabic script languages are only used in kurdish (sorani).
+	kurdishLetters = regexp.MustCompile(`[ڕڵۆێەڤ]`)
+	// letters which are written in their persian forms in persian.
+	arabicLetters  = regexp.MustCompile(`[ةيكى]`)
+	persianLetters = regexp.MustCompile(`[پچژگکی]`)
+
 	// ErrInvalidRegion returns error when we have a invalid region.
 	ErrInvalidRegion = errors.New("invalid region")
-
-	// https://en.wikipedia.org/wiki/Telephone_numbers_in_Iran
-	iranMobileRegexp = regexp.MustCompile(`^(0|\+98)[0-9]{10}$`)
-
-	turkeyMobileRegexp = regexp.MustCompile(`^\+90[0-9]{10}$`)
 )
 
-// ToRegion convert string region to Region type.
-// nolint:cyclop
+// ToRegion convert string region to Region type, region names and their aliases are accepted.
 func ToRegion(region string) (Region, error) {
-	switch region {
-	case "iran", "arvan":
-		return Arvan, nil
-	case "turkey":
-		return Turkey, nil
-	default:
-		return Invalid, ErrInvalidRegion
+	r := current()
+
+	name := strings.ToLower(region)
+
+	if _, ok := r.regions[Region(name)]; ok {
+		return Region(name), nil
+	}
+
+	if alias, ok := r.aliases[name]; ok {
+		return alias, nil
 	}
+
+	return Invalid, ErrInvalidRegion
 }
 
-// nolint:cyclop
 // String returns the string value of a region.
 func (r Region) String() string {
-	// nolint: exhaustive
-	switch r {
-	case Arvan:
-		return "arvan"
-	case Turkey:
-		return "turkey"
-	default:
+	if _, ok := Lookup(r); !ok {
 		return ""
 	}
+
+	return string(r)
 }
 
 // Append adds array of Region to Regions.
@@ -82,65 +84,93 @@ func Append(rs ...Region) Regions {
 
 // Contains checks input Region exists in Regions.
 func (rs Regions) Contains(r Region) bool {
-	return uint64(rs)&uint64(r) != 0
+	_, ok := rs[r]
+
+	return ok
 }
 
 // Append add region to Regions.
 func (rs *Regions) Append(r Region) {
-	*rs = Regions(uint64(*rs) | uint64(r))
+	if *rs == nil {
+		*rs = make(Regions)
+	}
+
+	(*rs)[r] = struct{}{}
 }
 
-// RegionRegexp returns regex based on input Region.
-// nolint:cyclop
+// RegionRegexp returns the mobile numbers regex of a region, in national or international format.
+// Arvan's regex is returned for unregistered regions.
 func RegionRegexp(region Region) *regexp.Regexp {
-	switch region {
-	case Arvan:
-		return iranMobileRegexp
-	case Turkey:
-		return turkeyMobileRegexp
-	case Invalid:
-		return iranMobileRegexp
-	default:
-		return iranMobileRegexp
+	if info, ok := Lookup(region); ok {
+		return info.mobileRegexp
+	}
+
+	if info, ok := Lookup(Arvan); ok {
+		return info.mobileRegexp
 	}
+
+	return matchNothing
 }
 
 // MatchRegionRegexp checks if a string matches to one of the provided regions or not.
 func MatchRegionRegexp(regions []string, stringToMatch string) bool {
+	_, ok := MatchRegion(regions, stringToMatch)
+
+	return ok
+}
+
+// MatchRegion returns the first of the provided regions which a string matches to.
+func MatchRegion(regions []string, stringToMatch string) (Region, bool) {
 	for _, region := range regions {
 		regionObj, err := ToRegion(region)
 		if err != nil {
 			continue
 		}
 
-		regexToCheck := RegionRegexp(regionObj)
-
-		if match := regexToCheck.MatchString(stringToMatch); !match {
-			continue
-		} else {
-			return true
+		if RegionRegexp(regionObj).MatchString(stringToMatch) {
+			return regionObj, true
 		}
 	}
 
-	return false
+	return Invalid, false
 }
 
-// IsMobileNumber detects if a string is a valid phone number or not.
-func IsMobileNumber(input string) bool {
-	if iranMobileRegexp.MatchString(input) {
-		return true
+// RegionNames returns the names of all registered regions.
+func RegionNames() []string {
+	regions := AllRegions()
+
+	names := make([]string, len(regions))
+	for i := range regions {
+		names[i] = regions[i].Name.String()
 	}
 
-	return false
+	return names
 }
 
-// DetectLanguage detects the language of a string.
+// IsMobileNumber detects if a string is a valid mobile number or not.
+func IsMobileNumber(input string) bool {
+	pn, err := Normalize(input, Arvan)
+
+	return err == nil && pn.Type == Mobile
+}
+
+// DetectLanguage detects the language of a string by its script.
+// Text with arabic script letters is kurdish or arabic when it has their specific letters,
+// otherwise its first arabic script character decides between persian and arabic.
 func DetectLanguage(payload string) string {
-	if persianAlphabet.MatchString(payload) {
+	loc := arabicScript.FindStringIndex(payload)
+	if loc == nil {
+		return EnglishLanguage
+	}
+
+	switch {
+	case kurdishLetters.MatchString(payload):
+		return KurdishLanguage
+	case arabicLetters.MatchString(payload) && !persianLetters.MatchString(payload):
+		return ArabicLanguage
+	case persianAlphabet.MatchString(payload[loc[0]:]):
 		return PersianLanguage
-	} else if arabicAlphabet.MatchString(payload) {
+	default:
 		return ArabicLanguage
 	}
-
-	return EnglishLanguage
 }
@@ -1,7 +1,6 @@
 package i18n
 
 import (
-	"regexp"
 	"testing"
 
 	"github.com/stretchr/testify/require"
@@ -170,7 +169,7 @@ func TestString(t *testing.T) {
 		},
 		{
 			name:     "invalid 2",
-			region:   Region(25),
+			region:   Region("mars"),
 			expected: "",
 		},
 	}
@@ -189,35 +188,35 @@ func TestRegionRegexp(t *testing.T) {
 
 	cases := []struct {
 		name     string
-		expected *regexp.Regexp
+		expected string
 		region   Region
 	}{
 		{
 			name:     "arvan",
 			region:   Arvan,
-			expected: iranMobileRegexp,
+			expected: `^(0|\+98)[0-9]{10}$`,
 		},
 		{
 			name:     "turkey",
 			region:   Turkey,
-			expected: turkeyMobileRegexp,
+			expected: `^\+90[0-9]{10}$`,
 		},
 		{
 			name:     "invalid 1",
 			region:   Invalid,
-			expected: iranMobileRegexp,
+			expected: `^(0|\+98)[0-9]{10}$`,
 		},
 		{
 			name:     "invalid 2",
-			region:   Region(25),
-			expected: iranMobileRegexp,
+			region:   Region("mars"),
+			expected: `^(0|\+98)[0-9]{10}$`,
 		},
 	}
 
 	for _, tt := range cases {
 		t.Run(tt.name, func(t *testing.T) {
 			t.Parallel()
-			require.Equal(t, tt.expected, RegionRegexp(tt.region))
+			require.Equal(t, tt.expected, RegionRegexp(tt.region).String())
 		})
 	}
 }
@@ -319,6 +318,36 @@ func TestDetectLanguage(t *testing.T) {
 			input:    "سلام arvan ؀",
 			language: PersianLanguage,
 		},
+		{
+			name:     "english first persian",
+			input:    "arvan سلام",
+			language: PersianLanguage,
+		},
+		{
+			name:     "persian sentence",
+			input:    "کد تایید شما ۱۲۳۴ است",
+			language: PersianLanguage,
+		},
+		{
+			name:     "arabic sentence",
+			input:    "رمز التحقق الخاص بك هو",
+			language: ArabicLanguage,
+		},
+		{
+			name:     "arabic sentence with arabic kaf and yeh",
+			input:    "مرحبا بكم في الخدمة",
+			language: ArabicLanguage,
+		},
+		{
+			name:     "kurdish sentence",
+			input:    "کۆدی پشتڕاستکردنەوەی تۆ",
+			language: KurdishLanguage,
+		},
+		{
+			name:     "digits",
+			input:    "1234",
+			language: EnglishLanguage,
+		},
 	}
 
 	for _, tt := range cases {
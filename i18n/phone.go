@@ -0,0 +1,147 @@
+package i18n
+
+import (
+	"errors"
+	"sort"
+	"strings"
+)
+
+// NumberType is the type of a phone number detected from its prefix.
+type NumberType string
+
+const (
+	// Mobile number type.
+	Mobile NumberType = "mobile"
+	// Landline number type.
+	Landline NumberType = "landline"
+	// Unknown type of numbers out of the registered regions.
+	Unknown NumberType = "unknown"
+
+	minE164Digits = 8
+	maxE164Digits = 15
+)
+
+// ErrInvalidPhoneNumber returns when a phone number cannot be normalized.
+var ErrInvalidPhoneNumber = errors.New("invalid phone number")
+
+// PhoneNumber is a normalized phone number with its country metadata.
+type PhoneNumber struct {
+	// E164 is the canonical format, e.g. +989121234567.
+	E164 string
+	// CallingCode is the country calling code, e.g. 98.
+	CallingCode string
+	// Country is the ISO 3166-1 alpha-2 code of the country, e.g. IR.
+	Country string
+	// Region is the registered region of the number or Invalid.
+	Region Region
+	Type   NumberType
+}
+
+// nolint: gochecknoglobals
+var (
+	separatorReplacer = strings.NewReplacer(" ", "", "-", "", "(", "", ")", "", ".", "")
+	digitReplacer     = strings.NewReplacer(
+		"۰", "0", "۱", "1", "۲", "2", "۳", "3", "۴", "4", "۵", "5", "۶", "6", "۷", "7", "۸", "8", "۹", "9",
+		"٠", "0", "١", "1", "٢", "2", "٣", "3", "٤", "4", "٥", "5", "٦", "6", "٧", "7", "٨", "8", "٩", "9",
+	)
+)
+
+// Normalize converts national and international formats of a phone number into E.164
+// and detects its country and type. National numbers are considered to be in the
+// preferred region first and then in the other registered regions.
+// e.g. 0912 123 4567, 00989121234567 and 989121234567 are all normalized to +989121234567.
+func Normalize(phoneNumber string, preferred Region) (PhoneNumber, error) {
+	number := digitReplacer.Replace(separatorReplacer.Replace(strings.TrimSpace(phoneNumber)))
+
+	switch {
+	case strings.HasPrefix(number, "+"):
+		return parseInternational(strings.TrimPrefix(number, "+"))
+	case strings.HasPrefix(number, "00"):
+		return parseInternational(strings.TrimPrefix(number, "00"))
+	}
+
+	if !isDigits(number) {
+		return PhoneNumber{}, ErrInvalidPhoneNumber
+	}
+
+	for _, ri := range candidates(preferred) {
+		if ri.NationalPrefix == "" || !strings.HasPrefix(number, ri.NationalPrefix) {
+			continue
+		}
+
+		if pn, ok := ri.parse(strings.TrimPrefix(number, ri.NationalPrefix)); ok {
+			return pn, nil
+		}
+	}
+
+	// international numbers without the leading plus, e.g. what providers report.
+	for _, ri := range byCallingCode() {
+		if !strings.HasPrefix(number, ri.CallingCode) {
+			continue
+		}
+
+		if pn, ok := ri.parse(strings.TrimPrefix(number, ri.CallingCode)); ok {
+			return pn, nil
+		}
+	}
+
+	return PhoneNumber{}, ErrInvalidPhoneNumber
+}
+
+// parseInternational parses a phone number which starts with its calling code.
+// numbers of unregistered countries are accepted with an Unknown type.
+func parseInternational(number string) (PhoneNumber, error) {
+	if !isDigits(number) || len(number) < minE164Digits || len(number) > maxE164Digits {
+		return PhoneNumber{}, ErrInvalidPhoneNumber
+	}
+
+	for _, ri := range byCallingCode() {
+		if !strings.HasPrefix(number, ri.CallingCode) {
+			continue
+		}
+
+		if pn, ok := ri.parse(strings.TrimPrefix(number, ri.CallingCode)); ok {
+			return pn, nil
+		}
+
+		return PhoneNumber{}, ErrInvalidPhoneNumber
+	}
+
+	return PhoneNumber{E164: "+" + number, Region: Invalid, Type: Unknown}, nil
+}
+
+// candidates returns the registered regions, starting with the preferred one.
+func candidates(preferred Region) []*RegionInfo {
+	regions := AllRegions()
+
+	sort.SliceStable(regions, func(i, j int) bool {
+		return regions[i].Name == preferred && regions[j].Name != preferred
+	})
+
+	return regions
+}
+
+// byCallingCode returns the registered regions, longest calling codes first.
+func byCallingCode() []*RegionInfo {
+	regions := AllRegions()
+
+	sort.SliceStable(regions, func(i, j int) bool {
+		return len(regions[i].CallingCode) > len(regions[j].CallingCode)
+	})
+
+	return regions
+}
+
+func isDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+
+	return true
+}
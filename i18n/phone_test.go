@@ -0,0 +1,156 @@
+package i18n
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// nolint:funlen
+func TestNormalize(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name      string
+		input     string
+		preferred Region
+		expected  PhoneNumber
+		err       error
+	}{
+		// iran
+		{
+			name:      "iran mobile national",
+			input:     "09121234567",
+			preferred: Arvan,
+			expected:  PhoneNumber{E164: "+989121234567", CallingCode: "98", Country: "IR", Region: Arvan, Type: Mobile},
+		},
+		{
+			name:      "iran mobile international",
+			input:     "+989121234567",
+			preferred: Arvan,
+			expected:  PhoneNumber{E164: "+989121234567", CallingCode: "98", Country: "IR", Region: Arvan, Type: Mobile},
+		},
+		{
+			name:      "iran mobile international with zeros",
+			input:     "00989121234567",
+			preferred: Arvan,
+			expected:  PhoneNumber{E164: "+989121234567", CallingCode: "98", Country: "IR", Region: Arvan, Type: Mobile},
+		},
+		{
+			name:      "iran mobile without plus",
+			input:     "989121234567",
+			preferred: Arvan,
+			expected:  PhoneNumber{E164: "+989121234567", CallingCode: "98", Country: "IR", Region: Arvan, Type: Mobile},
+		},
+		{
+			name:      "iran mobile with separators",
+			input:     "0912 123-4567",
+			preferred: Arvan,
+			expected:  PhoneNumber{E164: "+989121234567", CallingCode: "98", Country: "IR", Region: Arvan, Type: Mobile},
+		},
+		{
+			name:      "iran mobile with persian digits",
+			input:     "۰۹۱۲۱۲۳۴۵۶۷",
+			preferred: Arvan,
+			expected:  PhoneNumber{E164: "+989121234567", CallingCode: "98", Country: "IR", Region: Arvan, Type: Mobile},
+		},
+		{
+			name:      "iran mobile national in turkey",
+			input:     "09121234567",
+			preferred: Turkey,
+			expected:  PhoneNumber{E164: "+989121234567", CallingCode: "98", Country: "IR", Region: Arvan, Type: Mobile},
+		},
+		{
+			name:      "iran landline",
+			input:     "02188776655",
+			preferred: Arvan,
+			expected:  PhoneNumber{E164: "+982188776655", CallingCode: "98", Country: "IR", Region: Arvan, Type: Landline},
+		},
+		{
+			name:      "iran too short",
+			input:     "0912123456",
+			preferred: Arvan,
+			err:       ErrInvalidPhoneNumber,
+		},
+		{
+			name:      "iran too long international",
+			input:     "+9891212345678",
+			preferred: Arvan,
+			err:       ErrInvalidPhoneNumber,
+		},
+		// turkey
+		{
+			name:      "turkey mobile national",
+			input:     "05321234567",
+			preferred: Turkey,
+			expected:  PhoneNumber{E164: "+905321234567", CallingCode: "90", Country: "TR", Region: Turkey, Type: Mobile},
+		},
+		{
+			name:      "turkey mobile international",
+			input:     "+90 532 123 45 67",
+			preferred: Arvan,
+			expected:  PhoneNumber{E164: "+905321234567", CallingCode: "90", Country: "TR", Region: Turkey, Type: Mobile},
+		},
+		{
+			name:      "turkey landline",
+			input:     "+902121234567",
+			preferred: Turkey,
+			expected:  PhoneNumber{E164: "+902121234567", CallingCode: "90", Country: "TR", Region: Turkey, Type: Landline},
+		},
+		{
+			name:      "turkey invalid prefix",
+			input:     "+909121234567",
+			preferred: Turkey,
+			err:       ErrInvalidPhoneNumber,
+		},
+		// iraq
+		{
+			name:      "iraq mobile international",
+			input:     "+9647773041055",
+			preferred: Arvan,
+			expected:  PhoneNumber{E164: "+9647773041055", CallingCode: "964", Country: "IQ", Region: "iraq", Type: Mobile},
+		},
+		{
+			name:      "iraq baghdad landline",
+			input:     "+96415371234",
+			preferred: Arvan,
+			expected:  PhoneNumber{E164: "+96415371234", CallingCode: "964", Country: "IQ", Region: "iraq", Type: Landline},
+		},
+		// others
+		{
+			name:      "unsupported country",
+			input:     "+14155552671",
+			preferred: Arvan,
+			expected:  PhoneNumber{E164: "+14155552671", Region: Invalid, Type: Unknown},
+		},
+		{
+			name:      "national without prefix",
+			input:     "9121234567",
+			preferred: Arvan,
+			err:       ErrInvalidPhoneNumber,
+		},
+		{
+			name:      "alphabetical",
+			input:     "test",
+			preferred: Arvan,
+			err:       ErrInvalidPhoneNumber,
+		},
+		{
+			name:      "empty",
+			input:     "",
+			preferred: Arvan,
+			err:       ErrInvalidPhoneNumber,
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			pn, err := Normalize(tt.input, tt.preferred)
+
+			require.ErrorIs(t, err, tt.err)
+			require.Equal(t, tt.expected, pn)
+		})
+	}
+}
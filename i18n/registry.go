@@ -0,0 +1,227 @@
+package i18n
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	_ "time/tzdata" // regions' timezones should not depend on the host's zoneinfo
+
+	"arvanch/config"
+)
+
+// RegionInfo is a registered region with its numbering plan and localization settings.
+type RegionInfo struct {
+	Name            Region
+	Country         string
+	CallingCode     string
+	NationalPrefix  string
+	Locales         []string
+	Location        *time.Location
+	DefaultProvider string
+
+	// patterns are checked in order, so mobile numbers are detected first.
+	patterns     []numberPattern
+	mobileRegexp *regexp.Regexp
+}
+
+type numberPattern struct {
+	numberType NumberType
+	regexp     *regexp.Regexp
+}
+
+type registry struct {
+	regions map[Region]*RegionInfo
+	aliases map[string]Region
+	// names are sorted to keep lookups deterministic.
+	names []Region
+}
+
+// ErrInvalidRegionConfig returns when a region definition cannot be loaded.
+var ErrInvalidRegionConfig = errors.New("invalid region config")
+
+// nolint: gochecknoglobals
+var (
+	registryLock    sync.RWMutex
+	defaultRegistry = mustNewRegistry(config.Default().I18N.Regions)
+
+	// matchNothing is used for regions without mobile numbers.
+	matchNothing = regexp.MustCompile(`[^\x00-\x{10FFFF}]`)
+)
+
+// Load replaces the registered regions with the given definitions,
+// so a new country can be onboarded by configuration.
+func Load(regions map[string]config.Region) error {
+	r, err := newRegistry(regions)
+	if err != nil {
+		return err
+	}
+
+	registryLock.Lock()
+	defaultRegistry = r
+	registryLock.Unlock()
+
+	return nil
+}
+
+// Lookup returns the registered region.
+func Lookup(region Region) (*RegionInfo, bool) {
+	r := current()
+
+	info, ok := r.regions[region]
+
+	return info, ok
+}
+
+// AllRegions returns the registered regions ordered by name.
+func AllRegions() []*RegionInfo {
+	r := current()
+
+	infos := make([]*RegionInfo, 0, len(r.names))
+	for _, name := range r.names {
+		infos = append(infos, r.regions[name])
+	}
+
+	return infos
+}
+
+func current() *registry {
+	registryLock.RLock()
+	defer registryLock.RUnlock()
+
+	return defaultRegistry
+}
+
+func mustNewRegistry(regions map[string]config.Region) *registry {
+	r, err := newRegistry(regions)
+	if err != nil {
+		panic(err)
+	}
+
+	return r
+}
+
+func newRegistry(regions map[string]config.Region) (*registry, error) {
+	r := &registry{
+		regions: make(map[Region]*RegionInfo, len(regions)),
+		aliases: make(map[string]Region),
+	}
+
+	for name, cfg := range regions {
+		info, err := newRegionInfo(Region(strings.ToLower(name)), cfg)
+		if err != nil {
+			return nil, fmt.Errorf("%w: region %s: %s", ErrInvalidRegionConfig, name, err.Error())
+		}
+
+		r.regions[info.Name] = info
+		r.names = append(r.names, info.Name)
+
+		for _, alias := range cfg.Aliases {
+			r.aliases[strings.ToLower(alias)] = info.Name
+		}
+	}
+
+	sort.Slice(r.names, func(i, j int) bool { return r.names[i] < r.names[j] })
+
+	return r, nil
+}
+
+// nolint:err113
+func newRegionInfo(name Region, cfg config.Region) (*RegionInfo, error) {
+	if name == Invalid {
+		return nil, errors.New("empty name")
+	}
+
+	if !isDigits(cfg.CallingCode) {
+		return nil, fmt.Errorf("calling code must be digits: %q", cfg.CallingCode)
+	}
+
+	location := time.UTC
+
+	if cfg.Timezone != "" {
+		var err error
+
+		if location, err = time.LoadLocation(cfg.Timezone); err != nil {
+			return nil, err
+		}
+	}
+
+	info := &RegionInfo{
+		Name:            name,
+		Country:         strings.ToUpper(cfg.Country),
+		CallingCode:     cfg.CallingCode,
+		NationalPrefix:  cfg.NationalPrefix,
+		Locales:         cfg.Locales,
+		Location:        location,
+		DefaultProvider: cfg.DefaultProvider,
+		mobileRegexp:    matchNothing,
+	}
+
+	types := make([]string, 0, len(cfg.NumberPatterns))
+	for numberType := range cfg.NumberPatterns {
+		types = append(types, numberType)
+	}
+
+	sort.Slice(types, func(i, j int) bool {
+		if (types[i] == string(Mobile)) != (types[j] == string(Mobile)) {
+			return types[i] == string(Mobile)
+		}
+
+		return types[i] < types[j]
+	})
+
+	for _, numberType := range types {
+		pattern := cfg.NumberPatterns[numberType]
+
+		re, err := regexp.Compile(`^(?:` + pattern + `)$`)
+		if err != nil {
+			return nil, err
+		}
+
+		info.patterns = append(info.patterns, numberPattern{numberType: NumberType(numberType), regexp: re})
+
+		if NumberType(numberType) == Mobile {
+			info.mobileRegexp = regexp.MustCompile(`^` + info.prefixPattern() + `(?:` + pattern + `)$`)
+		}
+	}
+
+	if cfg.RecipientPattern != "" {
+		re, err := regexp.Compile(`^` + cfg.RecipientPattern + `$`)
+		if err != nil {
+			return nil, err
+		}
+
+		info.mobileRegexp = re
+	}
+
+	return info, nil
+}
+
+// prefixPattern matches the international or national prefix of the region's numbers.
+func (ri *RegionInfo) prefixPattern() string {
+	if ri.NationalPrefix == "" {
+		return `\+` + regexp.QuoteMeta(ri.CallingCode)
+	}
+
+	return `(?:\+` + regexp.QuoteMeta(ri.CallingCode) + `|` + regexp.QuoteMeta(ri.NationalPrefix) + `)`
+}
+
+// parse parses a national significant number of the region.
+func (ri *RegionInfo) parse(nsn string) (PhoneNumber, bool) {
+	for _, p := range ri.patterns {
+		if p.regexp.MatchString(nsn) {
+			return PhoneNumber{
+				E164:        "+" + ri.CallingCode + nsn,
+				CallingCode: ri.CallingCode,
+				Country:     ri.Country,
+				Region:      ri.Name,
+				Type:        p.numberType,
+			}, true
+		}
+	}
+
+	return PhoneNumber{}, false
+}
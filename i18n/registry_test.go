@@ -0,0 +1,77 @@
+package i18n
+
+import (
+	"testing"
+
+	"arvanch/config"
+
+	"github.com/stretchr/testify/require"
+)
+
+// TestLoad is not parallel since it replaces the registered regions.
+func TestLoad(t *testing.T) {
+	defer func() {
+		require.NoError(t, Load(config.Default().I18N.Regions))
+	}()
+
+	regions := config.Default().I18N.Regions
+	regions["uae"] = config.Region{
+		Aliases:        []string{"emirates"},
+		Country:        "ae",
+		CallingCode:    "971",
+		NationalPrefix: "0",
+		NumberPatterns: map[string]string{"mobile": `5[024568][0-9]{7}`},
+		Locales:        []string{"ar", "en"},
+		Timezone:       "Asia/Dubai",
+	}
+
+	require.NoError(t, Load(regions))
+
+	region, err := ToRegion("emirates")
+	require.NoError(t, err)
+	require.Equal(t, Region("uae"), region)
+	require.Equal(t, "uae", region.String())
+
+	info, ok := Lookup(region)
+	require.True(t, ok)
+	require.Equal(t, "AE", info.Country)
+	require.Equal(t, "Asia/Dubai", info.Location.String())
+
+	pn, err := Normalize("0501234567", region)
+	require.NoError(t, err)
+	require.Equal(t, PhoneNumber{E164: "+971501234567", CallingCode: "971", Country: "AE", Region: region, Type: Mobile}, pn)
+
+	require.True(t, MatchRegionRegexp([]string{"uae"}, "+971501234567"))
+	require.Contains(t, RegionNames(), "uae")
+}
+
+func TestLoadInvalid(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name   string
+		region config.Region
+	}{
+		{
+			name:   "invalid calling code",
+			region: config.Region{CallingCode: "+971"},
+		},
+		{
+			name:   "invalid pattern",
+			region: config.Region{CallingCode: "971", NumberPatterns: map[string]string{"mobile": "5[0-9"}},
+		},
+		{
+			name:   "invalid timezone",
+			region: config.Region{CallingCode: "971", Timezone: "Mars/Olympus"},
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := newRegistry(map[string]config.Region{"uae": tt.region})
+			require.ErrorIs(t, err, ErrInvalidRegionConfig)
+		})
+	}
+}
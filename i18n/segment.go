@@ -0,0 +1,52 @@
+package i18n
+
+import (
+	"strings"
+	"unicode/utf16"
+)
+
+const (
+	gsm7SingleLen    = 160
+	gsm7MultipartLen = 153
+	ucs2SingleLen    = 70
+	ucs2MultipartLen = 67
+)
+
+const (
+	// gsm7Alphabet is the GSM 03.38 basic character set.
+	gsm7Alphabet = "@£$¥èéùìòÇ\nØø\rÅåΔ_ΦΓΛΩΠΨΣΘΞÆæßÉ !\"#¤%&'()*+,-./0123456789:;<=>?" +
+		"¡ABCDEFGHIJKLMNOPQRSTUVWXYZÄÖÑÜ§¿abcdefghijklmnopqrstuvwxyzäöñüà"
+	// gsm7Extension characters are sent with an escape, taking two septets.
+	gsm7Extension = "^{}\\[~]|€\f"
+)
+
+// Segments returns the number of SMS parts needed to send the payload.
+// Payloads out of the GSM 7-bit alphabet, e.g. Persian and Arabic texts, are sent in UCS-2.
+func Segments(payload string) int {
+	length, gsm7 := 0, true
+
+	for _, r := range payload {
+		switch {
+		case strings.ContainsRune(gsm7Alphabet, r):
+			length++
+		case strings.ContainsRune(gsm7Extension, r):
+			length += 2
+		default:
+			gsm7 = false
+		}
+	}
+
+	single, multipart := gsm7SingleLen, gsm7MultipartLen
+
+	if !gsm7 {
+		// UCS-2 is counted in UTF-16 code units, characters out of the BMP take two.
+		length = len(utf16.Encode([]rune(payload)))
+		single, multipart = ucs2SingleLen, ucs2MultipartLen
+	}
+
+	if length <= single {
+		return 1
+	}
+
+	return (length + multipart - 1) / multipart
+}
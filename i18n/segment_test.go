@@ -0,0 +1,37 @@
+package i18n
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSegments(t *testing.T) {
+	t.Parallel()
+
+	tcs := []struct {
+		name     string
+		payload  string
+		expected int
+	}{
+		{name: "empty", payload: "", expected: 1},
+		{name: "gsm7 single", payload: strings.Repeat("a", 160), expected: 1},
+		{name: "gsm7 multipart", payload: strings.Repeat("a", 161), expected: 2},
+		{name: "gsm7 extension", payload: strings.Repeat("€", 80) + "a", expected: 2},
+		{name: "ucs2 single", payload: strings.Repeat("س", 70), expected: 1},
+		{name: "ucs2 multipart", payload: strings.Repeat("س", 135), expected: 3},
+		{name: "mixed", payload: strings.Repeat("a", 69) + "س", expected: 1},
+		{name: "emoji", payload: strings.Repeat("😀", 35) + "a", expected: 2},
+	}
+
+	for i := range tcs {
+		tc := tcs[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tc.expected, Segments(tc.payload))
+		})
+	}
+}
@@ -6,15 +6,56 @@ import (
 	"time"
 
 	"arvanch/config"
+	"arvanch/pkg/mask"
 	"arvanch/pkg/security"
 
 	"github.com/sirupsen/logrus"
 	"github.com/snowzach/rotatefilehook"
 )
 
+// Event is the type of an access log entry.
+type Event string
+
+const (
+	EventSMSSend           Event = "sms_send"
+	EventMessagesList      Event = "messages_list"
+	EventAccountCharge     Event = "account_charge"
+	EventAccountCreate     Event = "account_create"
+	EventProfileRead       Event = "profile_read"
+	EventDLRReceived       Event = "dlr_received"
+	EventInboundReceived   Event = "inbound_received"
+	EventSuppressionAdd    Event = "suppression_add"
+	EventSuppressionRemove Event = "suppression_remove"
+	EventSuppressionList   Event = "suppression_list"
+	EventSandboxSet        Event = "sandbox_set"
+	EventWhitelistSet      Event = "whitelist_set"
+	EventWhitelistRead     Event = "whitelist_read"
+	EventMessageSearch     Event = "message_search"
+)
+
+// maskedPayloadFields are the fields of request payloads holding phone numbers.
+var maskedPayloadFields = []string{"phone_number", "sender", "recipient"}
+
+// media of events about messages, other events are not about a media.
+var media = map[Event]string{
+	EventSMSSend:         "sms",
+	EventDLRReceived:     "sms",
+	EventInboundReceived: "sms",
+}
+
 type (
-	SMSLog struct {
+	// Entry is an access log entry. Middleware fills the request's metadata,
+	// handlers fill the rest with EntryFrom.
+	Entry struct {
+		Event         Event
 		UUID          string
+		RequestID     string
+		UserID        string
+		Method        string
+		Route         string
+		Status        int
+		Latency       time.Duration
+		TraceID       string
 		Payload       string
 		Recipient     string
 		XForwardedFor string
@@ -26,15 +67,25 @@ type (
 		MessageBytes  int
 	}
 
+	// Logger writes the access log. When payloads are not secured and maskRecipient is set,
+	// recipients are masked, including the phone numbers in request payloads, and their HMAC is written
+	// so entries can still be searched by recipient.
 	Logger struct {
 		securePayload    bool
+		maskRecipient    bool
 		logger           *logrus.Logger
 		payloadEncryptor security.PayloadTransformer
 		payloadHMAC      security.PayloadTransformer
 	}
 )
 
-func NewAccessLogger(cfg config.CustomAccessLogger) (*Logger, error) {
+// NewAccessLogger returns nil when the log has no output, as Middleware logs nothing then
+// and the logger's keys are not needed.
+func NewAccessLogger(cfg config.CustomAccessLogger, maskRecipient bool) (*Logger, error) {
+	if !cfg.HookEnable && !cfg.StdoutEnable {
+		return nil, nil // nolint:nilnil
+	}
+
 	logrusLogger := logrus.New()
 
 	if cfg.StdoutEnable {
@@ -45,16 +96,9 @@ func NewAccessLogger(cfg config.CustomAccessLogger) (*Logger, error) {
 
 	if cfg.HookEnable {
 		rotateFileHook, err := rotatefilehook.NewRotateFileHook(rotatefilehook.RotateFileConfig{
-			Filename: cfg.Path,
-			Level:    logrus.InfoLevel,
-			Formatter: &logrus.JSONFormatter{
-				TimestampFormat:  time.RFC3339,
-				DisableTimestamp: false,
-				FieldMap: logrus.FieldMap{
-					logrus.FieldKeyMsg:  "message",
-					logrus.FieldKeyTime: "timestamp",
-				},
-			},
+			Filename:  cfg.Path,
+			Level:     logrus.InfoLevel,
+			Formatter: formatter(),
 		})
 		if err != nil {
 			return nil, err
@@ -63,7 +107,12 @@ func NewAccessLogger(cfg config.CustomAccessLogger) (*Logger, error) {
 		logrusLogger.AddHook(rotateFileHook)
 	}
 
-	tr, err := security.NewAESTransformer(cfg.EncryptionKey)
+	tr, err := security.NewAESKeyring(cfg.EncryptionKeys)
+	if err != nil {
+		return nil, err
+	}
+
+	mac, err := security.NewHMACKeyring(cfg.HMACKeys)
 	if err != nil {
 		return nil, err
 	}
@@ -71,24 +120,48 @@ func NewAccessLogger(cfg config.CustomAccessLogger) (*Logger, error) {
 	return &Logger{
 		logger:           logrusLogger,
 		securePayload:    cfg.SecurePayload,
+		maskRecipient:    maskRecipient,
 		payloadEncryptor: tr,
-		payloadHMAC:      security.NewHMACTransformer(cfg.HMACKey),
+		payloadHMAC:      mac,
 	}, nil
 }
 
-func (l *Logger) LogSMS(smsLog *SMSLog) {
+// formatter formats entries of the access log file, which Reader reads.
+func formatter() logrus.Formatter {
+	return &logrus.JSONFormatter{
+		TimestampFormat:  time.RFC3339,
+		DisableTimestamp: false,
+		FieldMap: logrus.FieldMap{
+			logrus.FieldKeyMsg:  "message",
+			logrus.FieldKeyTime: "timestamp",
+		},
+	}
+}
+
+func (l *Logger) Log(entry *Entry) {
 	payloadEnc, payloadHMAC := "", ""
 	recipientEnc, recipientHMAC := "", ""
-	payload, recipient := smsLog.Payload, smsLog.Recipient
+	payload, recipient := entry.Payload, entry.Recipient
 
 	if l.securePayload {
-		payloadEnc, payloadHMAC = l.secure(smsLog.Payload)
-		recipientEnc, recipientHMAC = l.secure(smsLog.Recipient)
+		payloadEnc, payloadHMAC = l.secure(entry.Payload)
+		recipientEnc, recipientHMAC = l.secure(entry.Recipient)
 		payload, recipient = "", ""
+	} else if l.maskRecipient {
+		payload, recipient = mask.JSONFields(payload, maskedPayloadFields...), mask.Phone(recipient)
+		recipientHMAC = l.mac(entry.Recipient)
 	}
 
 	l.logger.WithFields(logrus.Fields{
-		"uuid":            smsLog.UUID,
+		"event":           entry.Event,
+		"uuid":            entry.UUID,
+		"request_id":      entry.RequestID,
+		"user_id":         entry.UserID,
+		"method":          entry.Method,
+		"route":           entry.Route,
+		"status":          entry.Status,
+		"latency_ms":      entry.Latency.Milliseconds(),
+		"trace_id":        entry.TraceID,
 		"payload_enc":     payloadEnc,
 		"payload_hmac":    payloadHMAC,
 		"recipient_enc":   recipientEnc,
@@ -96,15 +169,15 @@ func (l *Logger) LogSMS(smsLog *SMSLog) {
 		"payload":         payload,
 		"recipient":       recipient,
 		"secured":         l.securePayload,
-		"x_forwarded_for": smsLog.XForwardedFor,
-		"x_real_ip":       smsLog.XRealIP,
-		"remote_address":  smsLog.RemoteAddress,
-		"error":           smsLog.Error,
-		"message_length":  smsLog.MessageLength,
-		"message_bytes":   smsLog.MessageBytes,
-		"language":        smsLog.Language,
-		"media":           "sms",
-	}).Info("sms request received")
+		"x_forwarded_for": entry.XForwardedFor,
+		"x_real_ip":       entry.XRealIP,
+		"remote_address":  entry.RemoteAddress,
+		"error":           entry.Error,
+		"message_length":  entry.MessageLength,
+		"message_bytes":   entry.MessageBytes,
+		"language":        entry.Language,
+		"media":           media[entry.Event],
+	}).Info(string(entry.Event))
 }
 
 func (l *Logger) encrypt(field string) string {
@@ -118,8 +191,10 @@ func (l *Logger) encrypt(field string) string {
 	return encryptedField
 }
 
-func (l *Logger) secure(field string) (string, string) {
-	encryptedField := l.encrypt(field)
+func (l *Logger) mac(field string) string {
+	if field == "" {
+		return ""
+	}
 
 	fieldMac, err := l.payloadHMAC.Transform(field)
 	if err != nil {
@@ -128,5 +203,9 @@ func (l *Logger) secure(field string) (string, string) {
 		fieldMac = ""
 	}
 
-	return encryptedField, fieldMac
+	return fieldMac
+}
+
+func (l *Logger) secure(field string) (string, string) {
+	return l.encrypt(field), l.mac(field)
 }
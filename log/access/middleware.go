@@ -0,0 +1,74 @@
+package access
+
+import (
+	"time"
+
+	"arvanch/log"
+	"arvanch/pkg/requestid"
+	"arvanch/pkg/tracing"
+
+	"github.com/labstack/echo/v4"
+)
+
+const entryKey = "access_entry"
+
+// Middleware logs an entry of the event for each request after it is handled.
+// Handlers add the details of the event to the entry returned by EntryFrom.
+// Nothing is logged when logger is nil.
+func Middleware(logger *Logger, event Event) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		if logger == nil {
+			return next
+		}
+
+		return func(c echo.Context) error {
+			start := time.Now()
+			req := c.Request()
+
+			entry := &Entry{
+				Event:         event,
+				RequestID:     requestid.FromContext(req.Context()),
+				UserID:        req.Header.Get(log.UserIDHeader),
+				Method:        req.Method,
+				Route:         c.Path(),
+				TraceID:       tracing.TraceID(req.Context()),
+				XForwardedFor: req.Header.Get(echo.HeaderXForwardedFor),
+				XRealIP:       req.Header.Get(echo.HeaderXRealIP),
+				RemoteAddress: req.RemoteAddr,
+			}
+
+			c.Set(entryKey, entry)
+
+			err := next(c)
+			if err != nil {
+				// let the error handler write the response, so its status code is logged.
+				c.Error(err)
+
+				if entry.Error == "" {
+					entry.Error = err.Error()
+				}
+			}
+
+			entry.Status = c.Response().Status
+			entry.Latency = time.Since(start)
+
+			logger.Log(entry)
+
+			return err
+		}
+	}
+}
+
+// EntryFrom returns the access log entry of the request. It returns an entry
+// which is not logged when the route has no access log middleware, so handlers don't need to check it.
+func EntryFrom(c echo.Context) *Entry {
+	if entry, ok := c.Get(entryKey).(*Entry); ok {
+		return entry
+	}
+
+	entry := &Entry{}
+
+	c.Set(entryKey, entry)
+
+	return entry
+}
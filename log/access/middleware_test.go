@@ -0,0 +1,87 @@
+package access
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"arvanch/config"
+	"arvanch/log"
+	"arvanch/pkg/requestid"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMiddleware(t *testing.T) {
+	t.Parallel()
+
+	keyring := config.Keyring{Active: "1", Keys: map[string]string{"1": "key"}}
+
+	logger, err := NewAccessLogger(config.CustomAccessLogger{
+		StdoutEnable:   true,
+		EncryptionKeys: keyring,
+		HMACKeys:       keyring,
+	}, true)
+	require.NoError(t, err)
+
+	var out bytes.Buffer
+
+	logger.logger.SetOutput(&out)
+	logger.logger.SetFormatter(formatter())
+
+	e := echo.New()
+	e.Use(requestid.Middleware())
+
+	e.POST("/sms/:id", func(c echo.Context) error {
+		EntryFrom(c).Recipient = "+989121234567"
+
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid")
+	}, Middleware(logger, EventSMSSend))
+
+	e.GET("/profile", func(c echo.Context) error {
+		EntryFrom(c).Error = "not logged"
+
+		return c.NoContent(http.StatusOK)
+	}, Middleware(nil, EventProfileRead))
+
+	e.DELETE("/admin/suppressions", func(c echo.Context) error {
+		return c.NoContent(http.StatusNoContent)
+	}, Middleware(logger, EventSuppressionRemove))
+
+	for _, req := range []*http.Request{
+		httptest.NewRequest(http.MethodPost, "/sms/1", nil),
+		httptest.NewRequest(http.MethodGet, "/profile", nil),
+		httptest.NewRequest(http.MethodDelete, "/admin/suppressions", nil),
+	} {
+		req.Header.Set(log.UserIDHeader, "user")
+
+		e.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	decoder := json.NewDecoder(&out)
+
+	var entry map[string]interface{}
+
+	require.NoError(t, decoder.Decode(&entry))
+	require.Equal(t, "sms_send", entry["event"])
+	require.NotEmpty(t, entry["request_id"])
+	require.Equal(t, "sms", entry["media"])
+	require.Equal(t, "user", entry["user_id"])
+	require.Equal(t, "/sms/:id", entry["route"])
+	require.Equal(t, http.MethodPost, entry["method"])
+	require.Equal(t, float64(http.StatusBadRequest), entry["status"])
+	require.Equal(t, "+98912***4567", entry["recipient"], "recipients should be masked when payloads are not secured")
+
+	entry = map[string]interface{}{}
+
+	require.NoError(t, decoder.Decode(&entry), "the profile entry should not be logged")
+	require.Equal(t, "suppression_remove", entry["event"])
+	require.Equal(t, "/admin/suppressions", entry["route"])
+	require.Equal(t, float64(http.StatusNoContent), entry["status"])
+	require.Empty(t, entry["media"])
+
+	require.False(t, decoder.More(), "only two entries should be logged")
+}
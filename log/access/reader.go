@@ -0,0 +1,234 @@
+package access
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"arvanch/config"
+	"arvanch/pkg/security"
+
+	"github.com/sirupsen/logrus"
+)
+
+// gzipMagic is the header of gzip files, rotated logs may be compressed.
+var gzipMagic = []byte{0x1f, 0x8b}
+
+var ErrInsecureKeys = errors.New("keys file should not be accessible by group or others")
+
+// Keys are the keyrings the access log is secured with.
+// They are read from a file or the environment, not from the configuration,
+// so reading the access log needs access to the keys themselves.
+type Keys struct {
+	EncryptionKeys config.Keyring `json:"encryption-keys"`
+	HMACKeys       config.Keyring `json:"hmac-keys"`
+}
+
+// ParseKeys parses keys in JSON, e.g. {"encryption-keys": {"active": "1", "keys": {"1": "..."}}, "hmac-keys": {...}}.
+func ParseKeys(data []byte) (Keys, error) {
+	var keys Keys
+
+	if err := json.Unmarshal(data, &keys); err != nil {
+		return Keys{}, fmt.Errorf("invalid keys: %w", err)
+	}
+
+	return keys, nil
+}
+
+// ReadKeysFile reads keys from a file, which should only be accessible by its owner.
+func ReadKeysFile(path string) (Keys, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return Keys{}, err
+	}
+
+	if info.Mode().Perm()&0o077 != 0 {
+		return Keys{}, fmt.Errorf("%w: %s has mode %s", ErrInsecureKeys, path, info.Mode().Perm())
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Keys{}, err
+	}
+
+	return ParseKeys(data)
+}
+
+// Query filters access log entries, zero fields match all entries.
+// Recipient is the E164 form of the phone number, From is inclusive and To is exclusive.
+type Query struct {
+	Recipient string
+	UUID      string
+	From      time.Time
+	To        time.Time
+}
+
+// Reader reads access log entries, decrypting secured fields.
+type Reader struct {
+	decryptor *security.AESKeyring
+	hmac      *security.HMACKeyring
+}
+
+func NewReader(keys Keys) (*Reader, error) {
+	decryptor, err := security.NewAESKeyring(keys.EncryptionKeys)
+	if err != nil {
+		return nil, err
+	}
+
+	hmac, err := security.NewHMACKeyring(keys.HMACKeys)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Reader{decryptor: decryptor, hmac: hmac}, nil
+}
+
+// Read streams the entries of the log in matching the query to out as JSON lines,
+// with payload and recipient decrypted. in may be gzip compressed.
+// It returns the number of entries written.
+func (r *Reader) Read(in io.Reader, out io.Writer, query Query) (int, error) {
+	recipientHMACs := map[string]bool{}
+
+	if query.Recipient != "" {
+		candidates, err := r.hmac.Candidates(query.Recipient)
+		if err != nil {
+			return 0, err
+		}
+
+		for _, mac := range candidates {
+			recipientHMACs[mac] = true
+		}
+	}
+
+	lines, err := decompress(in)
+	if err != nil {
+		return 0, err
+	}
+
+	encoder := json.NewEncoder(out)
+	written := 0
+
+	for number := 1; ; number++ {
+		line, err := lines.ReadBytes('\n')
+		if len(bytes.TrimSpace(line)) != 0 {
+			entry, ok := r.match(number, line, query, recipientHMACs)
+			if ok {
+				if err := encoder.Encode(entry); err != nil {
+					return written, err
+				}
+
+				written++
+			}
+		}
+
+		if errors.Is(err, io.EOF) {
+			return written, nil
+		}
+
+		if err != nil {
+			return written, err
+		}
+	}
+}
+
+// match parses an entry and decrypts it when it matches the query.
+// Lines which are not entries are skipped with a warning, as rotated logs may end with a partial line.
+func (r *Reader) match(number int, line []byte, query Query, recipientHMACs map[string]bool) (map[string]interface{}, bool) {
+	var entry map[string]interface{}
+
+	if err := json.Unmarshal(line, &entry); err != nil {
+		logrus.Warnf("skipping line %d, it is not a log entry: %s", number, err.Error())
+
+		return nil, false
+	}
+
+	if query.UUID != "" && stringField(entry, "uuid") != query.UUID {
+		return nil, false
+	}
+
+	if !query.From.IsZero() || !query.To.IsZero() {
+		timestamp, err := time.Parse(time.RFC3339, stringField(entry, "timestamp"))
+		if err != nil {
+			logrus.Warnf("skipping line %d, invalid timestamp: %s", number, err.Error())
+
+			return nil, false
+		}
+
+		if timestamp.Before(query.From) || (!query.To.IsZero() && !timestamp.Before(query.To)) {
+			return nil, false
+		}
+	}
+
+	secured, _ := entry["secured"].(bool)
+
+	if query.Recipient != "" {
+		// secured and masked entries have the recipient's HMAC, other entries have the recipient in plaintext.
+		recipientHMAC := stringField(entry, "recipient_hmac")
+
+		if (secured || recipientHMAC != "") && !recipientHMACs[recipientHMAC] {
+			return nil, false
+		}
+
+		if !secured && recipientHMAC == "" && stringField(entry, "recipient") != query.Recipient {
+			return nil, false
+		}
+	}
+
+	if secured {
+		r.decrypt(number, entry, "payload")
+		r.decrypt(number, entry, "recipient")
+	}
+
+	return entry, true
+}
+
+// decrypt replaces the encrypted field with its plaintext. The encrypted field is kept
+// when it can not be decrypted, e.g. its key was removed from the keyring.
+func (r *Reader) decrypt(number int, entry map[string]interface{}, field string) {
+	encrypted := stringField(entry, field+"_enc")
+	if encrypted == "" {
+		return
+	}
+
+	plaintext, err := r.decryptor.Decrypt(encrypted)
+	if err != nil {
+		logrus.Warnf("failed to decrypt %s of line %d: %s", field, number, err.Error())
+
+		return
+	}
+
+	entry[field] = plaintext
+	delete(entry, field+"_enc")
+}
+
+func decompress(in io.Reader) (*bufio.Reader, error) {
+	buffered := bufio.NewReader(in)
+
+	header, err := buffered.Peek(len(gzipMagic))
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+
+	if !bytes.Equal(header, gzipMagic) {
+		return buffered, nil
+	}
+
+	gz, err := gzip.NewReader(buffered)
+	if err != nil {
+		return nil, err
+	}
+
+	return bufio.NewReader(gz), nil
+}
+
+func stringField(entry map[string]interface{}, key string) string {
+	value, _ := entry[key].(string)
+
+	return value
+}
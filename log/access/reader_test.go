@@ -0,0 +1,145 @@
+package access
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"arvanch/config"
+
+	"github.com/stretchr/testify/require"
+)
+
+// nolint:funlen
+func TestReader(t *testing.T) {
+	t.Parallel()
+
+	keyring := config.Keyring{Active: "1", Keys: map[string]string{"1": "key"}}
+	keys := Keys{EncryptionKeys: keyring, HMACKeys: keyring}
+
+	logger, err := NewAccessLogger(config.CustomAccessLogger{
+		StdoutEnable:   true,
+		SecurePayload:  true,
+		EncryptionKeys: keys.EncryptionKeys,
+		HMACKeys:       keys.HMACKeys,
+	}, false)
+	require.NoError(t, err)
+
+	var log bytes.Buffer
+
+	logger.logger.SetOutput(&log)
+	logger.logger.SetFormatter(formatter())
+
+	logger.Log(&Entry{Event: EventSMSSend, UUID: "a", Recipient: "+989121234567", Payload: "first"})
+	logger.Log(&Entry{Event: EventSMSSend, UUID: "b", Recipient: "+989127654321", Payload: "second"})
+	logger.Log(&Entry{Event: EventSMSSend, UUID: "c", Recipient: "+989121234567", Payload: "third"})
+	log.WriteString("{\"partial")
+
+	reader, err := NewReader(keys)
+	require.NoError(t, err)
+
+	read := func(data []byte, query Query) []map[string]interface{} {
+		var out bytes.Buffer
+
+		n, err := reader.Read(bytes.NewReader(data), &out, query)
+		require.NoError(t, err)
+
+		var entries []map[string]interface{}
+
+		scanner := bufio.NewScanner(&out)
+		for scanner.Scan() {
+			var entry map[string]interface{}
+			require.NoError(t, json.Unmarshal(scanner.Bytes(), &entry))
+
+			entries = append(entries, entry)
+		}
+
+		require.Len(t, entries, n)
+
+		return entries
+	}
+
+	entries := read(log.Bytes(), Query{Recipient: "+989121234567"})
+	require.Len(t, entries, 2)
+	require.Equal(t, "first", entries[0]["payload"])
+	require.Equal(t, "+989121234567", entries[0]["recipient"])
+	require.NotContains(t, entries[0], "payload_enc")
+	require.Equal(t, "third", entries[1]["payload"])
+
+	entries = read(log.Bytes(), Query{UUID: "b"})
+	require.Len(t, entries, 1)
+	require.Equal(t, "second", entries[0]["payload"])
+
+	require.Len(t, read(log.Bytes(), Query{From: time.Now().Add(-time.Minute)}), 3)
+	require.Empty(t, read(log.Bytes(), Query{To: time.Now().Add(-time.Minute)}))
+
+	var compressed bytes.Buffer
+
+	gz := gzip.NewWriter(&compressed)
+	_, err = gz.Write(log.Bytes())
+	require.NoError(t, err)
+	require.NoError(t, gz.Close())
+
+	require.Len(t, read(compressed.Bytes(), Query{}), 3)
+}
+
+func TestReadKeysFile(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "keys.json")
+	data := []byte(`{"encryption-keys": {"active": "1", "keys": {"1": "key"}}, "hmac-keys": {"active": "2", "keys": {"2": "mac"}}}`)
+
+	require.NoError(t, os.WriteFile(path, data, 0o644))
+
+	_, err := ReadKeysFile(path)
+	require.ErrorIs(t, err, ErrInsecureKeys)
+
+	require.NoError(t, os.Chmod(path, 0o600))
+
+	keys, err := ReadKeysFile(path)
+	require.NoError(t, err)
+	require.Equal(t, "1", keys.EncryptionKeys.Active)
+	require.Equal(t, map[string]string{"2": "mac"}, keys.HMACKeys.Keys)
+}
+
+func TestReaderMaskedRecipients(t *testing.T) {
+	t.Parallel()
+
+	keyring := config.Keyring{Active: "1", Keys: map[string]string{"1": "key"}}
+	keys := Keys{EncryptionKeys: keyring, HMACKeys: keyring}
+
+	logger, err := NewAccessLogger(config.CustomAccessLogger{
+		StdoutEnable:   true,
+		EncryptionKeys: keys.EncryptionKeys,
+		HMACKeys:       keys.HMACKeys,
+	}, true)
+	require.NoError(t, err)
+
+	var log bytes.Buffer
+
+	logger.logger.SetOutput(&log)
+	logger.logger.SetFormatter(formatter())
+
+	logger.Log(&Entry{Event: EventSMSSend, UUID: "a", Recipient: "+989121234567", Payload: "first"})
+	logger.Log(&Entry{Event: EventSMSSend, UUID: "b", Recipient: "+989127654321", Payload: "second"})
+	logger.Log(&Entry{Event: EventProfileRead, UUID: "c"})
+
+	reader, err := NewReader(keys)
+	require.NoError(t, err)
+
+	var out bytes.Buffer
+
+	n, err := reader.Read(bytes.NewReader(log.Bytes()), &out, Query{Recipient: "+989121234567"})
+	require.NoError(t, err)
+	require.Equal(t, 1, n, "masked entries should be found by the recipient's HMAC")
+
+	var entry map[string]interface{}
+	require.NoError(t, json.Unmarshal(out.Bytes(), &entry))
+	require.Equal(t, "a", entry["uuid"])
+	require.Equal(t, "+98912***4567", entry["recipient"], "recipients should stay masked")
+}
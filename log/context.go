@@ -0,0 +1,63 @@
+package log
+
+import (
+	"context"
+
+	"github.com/labstack/echo/v4"
+	"github.com/sirupsen/logrus"
+)
+
+// UserIDHeader is the header users are identified by.
+const UserIDHeader = "X-USER-ID"
+
+type fieldsKey struct{}
+
+// WithFields returns a context carrying the fields in addition to the ones of ctx,
+// entries logged with FromContext include them.
+func WithFields(ctx context.Context, fields logrus.Fields) context.Context {
+	merged := logrus.Fields{}
+
+	for k, v := range fieldsFrom(ctx) {
+		merged[k] = v
+	}
+
+	for k, v := range fields {
+		merged[k] = v
+	}
+
+	return context.WithValue(ctx, fieldsKey{}, merged)
+}
+
+// FromContext returns a logger with the request-scoped fields of ctx,
+// e.g. log.FromContext(c.Request().Context()).Info(...).
+func FromContext(ctx context.Context) *logrus.Entry {
+	return logrus.WithContext(ctx).WithFields(fieldsFrom(ctx))
+}
+
+func fieldsFrom(ctx context.Context) logrus.Fields {
+	fields, _ := ctx.Value(fieldsKey{}).(logrus.Fields)
+
+	return fields
+}
+
+// Middleware adds the request-scoped fields to the context of each request.
+func Middleware() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			req := c.Request()
+
+			fields := logrus.Fields{
+				"method": req.Method,
+				"route":  c.Path(),
+			}
+
+			if userID := req.Header.Get(UserIDHeader); userID != "" {
+				fields["user_id"] = userID
+			}
+
+			c.SetRequest(req.WithContext(WithFields(req.Context(), fields)))
+
+			return next(c)
+		}
+	}
+}
@@ -0,0 +1,43 @@
+package log
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFromContext(t *testing.T) {
+	t.Parallel()
+
+	ctx := WithFields(context.Background(), logrus.Fields{"user_id": "1", "route": "/a"})
+	ctx = WithFields(ctx, logrus.Fields{"route": "/b"})
+
+	require.Equal(t, logrus.Fields{"user_id": "1", "route": "/b"}, FromContext(ctx).Data)
+	require.Empty(t, FromContext(context.Background()).Data)
+}
+
+func TestMiddleware(t *testing.T) {
+	t.Parallel()
+
+	var fields logrus.Fields
+
+	e := echo.New()
+	e.Use(Middleware())
+	e.GET("/messages/:id", func(c echo.Context) error {
+		fields = FromContext(c.Request().Context()).Data
+
+		return c.NoContent(http.StatusOK)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/messages/1", nil)
+	req.Header.Set(UserIDHeader, "user")
+
+	e.ServeHTTP(httptest.NewRecorder(), req)
+
+	require.Equal(t, logrus.Fields{"method": http.MethodGet, "route": "/messages/:id", "user_id": "user"}, fields)
+}
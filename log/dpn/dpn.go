@@ -0,0 +1,127 @@
+package dpn
+
+import (
+	"io"
+	"os"
+	"time"
+
+	"arvanch/config"
+	"arvanch/pkg/mask"
+
+	"github.com/sirupsen/logrus"
+	"github.com/snowzach/rotatefilehook"
+)
+
+// Media is the media of a message, each media is logged to its own file.
+type Media string
+
+const (
+	MediaSMS      Media = "sms"
+	MediaEmail    Media = "email"
+	MediaVoice    Media = "voice"
+	MediaWhatsapp Media = "whatsapp"
+)
+
+// Record is the delivery notification of a message which reached a final status.
+type Record struct {
+	MessageID string
+	UserID    string
+	Recipient string
+	Status    string
+	Provider  string
+	ErrorCode string
+	Segments  int
+	Price     int64
+	SentAt    time.Time
+	DoneAt    time.Time
+}
+
+// Logger writes one record per message final status, for billing reconciliation.
+// Nothing is logged when it is not enabled.
+type Logger struct {
+	maskRecipient bool
+	loggers       map[Media]*logrus.Logger
+}
+
+func NewDPNLogger(cfg config.DPNLogger, maskRecipient bool) (*Logger, error) {
+	paths := map[Media]string{
+		MediaSMS:      cfg.SMSPath,
+		MediaEmail:    cfg.EmailPath,
+		MediaVoice:    cfg.VoicePath,
+		MediaWhatsapp: cfg.WhatsappPath,
+	}
+
+	l := &Logger{
+		maskRecipient: maskRecipient,
+		loggers:       make(map[Media]*logrus.Logger, len(paths)),
+	}
+
+	if !cfg.Enable {
+		return l, nil
+	}
+
+	for media, path := range paths {
+		logrusLogger := logrus.New()
+		logrusLogger.SetFormatter(formatter())
+
+		if cfg.StdoutEnable {
+			logrusLogger.SetOutput(os.Stdout)
+		} else {
+			logrusLogger.SetOutput(io.Discard)
+		}
+
+		if cfg.HookEnable && path != "" {
+			rotateFileHook, err := rotatefilehook.NewRotateFileHook(rotatefilehook.RotateFileConfig{
+				Filename:  path,
+				Level:     logrus.InfoLevel,
+				Formatter: formatter(),
+			})
+			if err != nil {
+				return nil, err
+			}
+
+			logrusLogger.AddHook(rotateFileHook)
+		}
+
+		l.loggers[media] = logrusLogger
+	}
+
+	return l, nil
+}
+
+func formatter() logrus.Formatter {
+	return &logrus.JSONFormatter{
+		TimestampFormat: time.RFC3339,
+		FieldMap: logrus.FieldMap{
+			logrus.FieldKeyMsg:  "message",
+			logrus.FieldKeyTime: "timestamp",
+		},
+	}
+}
+
+// Log writes the record to the log of the media.
+func (l *Logger) Log(media Media, record Record) {
+	logger, ok := l.loggers[media]
+	if !ok {
+		return
+	}
+
+	recipient := record.Recipient
+	if l.maskRecipient {
+		recipient = mask.Phone(recipient)
+	}
+
+	logger.WithFields(logrus.Fields{
+		"message_id": record.MessageID,
+		"user_id":    record.UserID,
+		"media":      media,
+		"recipient":  recipient,
+		"status":     record.Status,
+		"provider":   record.Provider,
+		"error_code": record.ErrorCode,
+		"segments":   record.Segments,
+		"price":      record.Price,
+		"sent_at":    record.SentAt.Format(time.RFC3339),
+		"done_at":    record.DoneAt.Format(time.RFC3339),
+	}).Info("delivery notification")
+}
@@ -0,0 +1,70 @@
+package dpn
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"arvanch/config"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLogger(t *testing.T) {
+	t.Parallel()
+
+	record := Record{
+		MessageID: "1",
+		Recipient: "+989121234567",
+		Status:    "delivered",
+		Price:     100,
+		SentAt:    time.Date(2026, 10, 18, 10, 0, 0, 0, time.UTC),
+		DoneAt:    time.Date(2026, 10, 18, 10, 0, 5, 0, time.UTC),
+	}
+
+	cases := []struct {
+		name          string
+		enable        bool
+		maskRecipient bool
+		recipient     string
+	}{
+		{name: "masked", enable: true, maskRecipient: true, recipient: "+98912***4567"},
+		{name: "not masked", enable: true, recipient: "+989121234567"},
+		{name: "disabled", maskRecipient: true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			logger, err := NewDPNLogger(config.DPNLogger{Enable: tc.enable}, tc.maskRecipient)
+			require.NoError(t, err)
+
+			var sms, email bytes.Buffer
+
+			if tc.enable {
+				logger.loggers[MediaSMS].SetOutput(&sms)
+				logger.loggers[MediaEmail].SetOutput(&email)
+			}
+
+			logger.Log(MediaSMS, record)
+
+			require.Zero(t, email.Len(), "records should only be written to the log of their media")
+
+			if !tc.enable {
+				require.Zero(t, sms.Len())
+
+				return
+			}
+
+			var logged map[string]interface{}
+
+			require.NoError(t, json.Unmarshal(sms.Bytes(), &logged))
+			require.Equal(t, tc.recipient, logged["recipient"])
+			require.Equal(t, "sms", logged["media"])
+			require.Equal(t, "delivered", logged["status"])
+			require.Equal(t, "2026-10-18T10:00:05Z", logged["done_at"])
+		})
+	}
+}
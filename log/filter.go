@@ -0,0 +1,147 @@
+package log
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"arvanch/config"
+
+	"github.com/sirupsen/logrus"
+)
+
+// filter drops entries below the level of their package and samples debug entries
+// before they are formatted by next.
+type filter struct {
+	next     logrus.Formatter
+	level    logrus.Level
+	packages map[string]logrus.Level
+	sampler  *sampler
+}
+
+func newFilter(level logrus.Level, packages map[string]string, sampling config.Sampling) (*filter, error) {
+	f := &filter{
+		level:    level,
+		packages: make(map[string]logrus.Level, len(packages)),
+	}
+
+	for pkg, name := range packages {
+		l, err := logrus.ParseLevel(name)
+		if err != nil {
+			return nil, err
+		}
+
+		f.packages[pkg] = l
+	}
+
+	if sampling.Initial > 0 {
+		f.sampler = &sampler{
+			initial:    sampling.Initial,
+			thereafter: sampling.Thereafter,
+			tick:       sampling.Tick,
+			counts:     map[string]int{},
+		}
+	}
+
+	return f, nil
+}
+
+// maxLevel returns the most verbose level of all packages.
+func (f *filter) maxLevel() logrus.Level {
+	level := f.level
+
+	for _, l := range f.packages {
+		if l > level {
+			level = l
+		}
+	}
+
+	return level
+}
+
+func (f *filter) Format(entry *logrus.Entry) ([]byte, error) {
+	if !f.enabled(entry) {
+		return nil, nil
+	}
+
+	return f.next.Format(entry)
+}
+
+func (f *filter) enabled(entry *logrus.Entry) bool {
+	level := f.level
+
+	if entry.HasCaller() {
+		if l, ok := f.packageLevel(packageOf(entry.Caller.Function)); ok {
+			level = l
+		}
+	}
+
+	if entry.Level > level {
+		return false
+	}
+
+	if entry.Level >= logrus.DebugLevel && f.sampler != nil {
+		return f.sampler.allow(entry.Message)
+	}
+
+	return true
+}
+
+// packageLevel returns the level of the package, or of its closest parent package with a level.
+func (f *filter) packageLevel(pkg string) (logrus.Level, bool) {
+	var (
+		level   logrus.Level
+		matched string
+		found   bool
+	)
+
+	for p, l := range f.packages {
+		if (pkg == p || strings.HasPrefix(pkg, p+"/")) && len(p) > len(matched) {
+			level, matched, found = l, p, true
+		}
+	}
+
+	return level, found
+}
+
+// packageOf returns the import path of a function's package,
+// e.g. arvanch/repository for arvanch/repository.(*MessageRepo).InsertMessage.
+func packageOf(function string) string {
+	slash := strings.LastIndex(function, "/")
+
+	if dot := strings.Index(function[slash+1:], "."); dot >= 0 {
+		return function[:slash+1+dot]
+	}
+
+	return function
+}
+
+// sampler allows the first initial identical messages in each tick, then every thereafter-th one.
+type sampler struct {
+	initial    int
+	thereafter int
+	tick       time.Duration
+
+	mu     sync.Mutex
+	start  time.Time
+	counts map[string]int
+}
+
+func (s *sampler) allow(message string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if now := time.Now(); now.Sub(s.start) >= s.tick {
+		s.start = now
+		s.counts = map[string]int{}
+	}
+
+	s.counts[message]++
+
+	n := s.counts[message]
+	if n <= s.initial {
+		return true
+	}
+
+	return s.thereafter > 0 && (n-s.initial)%s.thereafter == 0
+}
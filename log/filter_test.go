@@ -0,0 +1,68 @@
+package log
+
+import (
+	"runtime"
+	"testing"
+	"time"
+
+	"arvanch/config"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPackageOf(t *testing.T) {
+	t.Parallel()
+
+	require.Equal(t, "arvanch/repository", packageOf("arvanch/repository.(*MessageRepo).InsertMessage"))
+	require.Equal(t, "arvanch/log/access", packageOf("arvanch/log/access.Middleware.func1.1"))
+	require.Equal(t, "main", packageOf("main.main"))
+}
+
+func TestFilter(t *testing.T) {
+	t.Parallel()
+
+	f, err := newFilter(logrus.InfoLevel, map[string]string{
+		"arvanch/repository": "debug",
+		"arvanch/db":         "error",
+		"arvanch/db/replica": "warn",
+	}, config.Sampling{})
+	require.NoError(t, err)
+	require.Equal(t, logrus.DebugLevel, f.maxLevel())
+
+	entry := func(level logrus.Level, function string) *logrus.Entry {
+		e := logrus.NewEntry(logrus.New())
+		e.Level = level
+		e.Caller = &runtime.Frame{Function: function}
+		e.Logger.SetReportCaller(true)
+
+		return e
+	}
+
+	require.True(t, f.enabled(entry(logrus.DebugLevel, "arvanch/repository.(*MessageRepo).InsertMessage")))
+	require.False(t, f.enabled(entry(logrus.DebugLevel, "arvanch/handler.SMSHandler.Sms")))
+	require.True(t, f.enabled(entry(logrus.InfoLevel, "arvanch/handler.SMSHandler.Sms")))
+	require.False(t, f.enabled(entry(logrus.WarnLevel, "arvanch/db.Create")))
+	require.True(t, f.enabled(entry(logrus.WarnLevel, "arvanch/db/replica.Read")), "the closest package should be used")
+
+	_, err = newFilter(logrus.InfoLevel, map[string]string{"arvanch/db": "loud"}, config.Sampling{})
+	require.Error(t, err)
+}
+
+func TestSampler(t *testing.T) {
+	t.Parallel()
+
+	s := &sampler{initial: 2, thereafter: 3, tick: time.Hour, counts: map[string]int{}}
+
+	allowed := 0
+
+	for range 8 {
+		if s.allow("noisy") {
+			allowed++
+		}
+	}
+
+	// the first 2, then the 5th and 8th.
+	require.Equal(t, 4, allowed)
+	require.True(t, s.allow("other"), "messages should be sampled separately")
+}
@@ -4,26 +4,57 @@ import (
 	"time"
 
 	"arvanch/config"
+	"arvanch/pkg/tracing"
+
 	"github.com/sirupsen/logrus"
 )
 
-func SetupLogger(logger config.Logger) {
+// SetupLogger sets up the standard logger, phone numbers are masked when maskRecipient is set.
+func SetupLogger(logger config.Logger, maskRecipient bool) error {
 	logLevel, err := logrus.ParseLevel(logger.Level)
 	if err != nil {
 		logLevel = logrus.ErrorLevel
 	}
 
-	logrus.SetLevel(logLevel)
+	out, err := openOutputs(logger)
+	if err != nil {
+		return err
+	}
+
+	filter, err := newFilter(logLevel, logger.Packages, logger.Sampling)
+	if err != nil {
+		return err
+	}
+
+	logrus.SetOutput(out)
+	// entries are filtered by the level of their package, the standard logger lets all of them through.
+	logrus.SetLevel(filter.maxLevel())
+	logrus.AddHook(tracing.LogrusHook{})
 
-	if logLevel == logrus.DebugLevel {
-		logrus.SetReportCaller(true)
-		logrus.SetFormatter(&logrus.TextFormatter{
+	if maskRecipient {
+		logrus.AddHook(MaskHook{})
+	}
+
+	// packages are found by the caller of entries.
+	logrus.SetReportCaller(logLevel == logrus.DebugLevel || len(logger.Packages) != 0)
+
+	format := logger.Format
+	if format == "" && logLevel == logrus.DebugLevel {
+		format = "text"
+	}
+
+	if format == "text" {
+		filter.next = &logrus.TextFormatter{
 			FullTimestamp:   true,
 			TimestampFormat: time.RFC3339,
-		})
+		}
 	} else {
-		logrus.SetFormatter(&logrus.JSONFormatter{
+		filter.next = &logrus.JSONFormatter{
 			TimestampFormat: time.RFC3339,
-		})
+		}
 	}
+
+	logrus.SetFormatter(filter)
+
+	return nil
 }
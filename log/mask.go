@@ -0,0 +1,28 @@
+package log
+
+import (
+	"arvanch/pkg/mask"
+
+	"github.com/sirupsen/logrus"
+)
+
+// MaskHook masks phone numbers logged in the fields of recipients,
+// e.g. logrus.WithField("recipient", recipient.E164).Info(...).
+type MaskHook struct{}
+
+// maskedFields are the fields holding phone numbers.
+var maskedFields = []string{"recipient", "sender", "phone_number"}
+
+func (MaskHook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+func (MaskHook) Fire(entry *logrus.Entry) error {
+	for _, field := range maskedFields {
+		if value, ok := entry.Data[field].(string); ok {
+			entry.Data[field] = mask.Phone(value)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,29 @@
+package log
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMaskHook(t *testing.T) {
+	t.Parallel()
+
+	var out bytes.Buffer
+
+	logger := logrus.New()
+	logger.SetOutput(&out)
+	logger.SetFormatter(&logrus.JSONFormatter{})
+	logger.AddHook(MaskHook{})
+
+	logger.WithFields(logrus.Fields{"recipient": "+989121234567", "user_id": "1"}).Info("sent")
+
+	var entry map[string]interface{}
+
+	require.NoError(t, json.Unmarshal(out.Bytes(), &entry))
+	require.Equal(t, "+98912***4567", entry["recipient"])
+	require.Equal(t, "1", entry["user_id"])
+}
@@ -0,0 +1,73 @@
+package log
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log/syslog"
+	"os"
+
+	"arvanch/config"
+
+	"gopkg.in/natefinch/lumberjack.v2"
+)
+
+const (
+	OutputStderr = "stderr"
+	OutputStdout = "stdout"
+	OutputFile   = "file"
+	OutputSyslog = "syslog"
+)
+
+var ErrUnknownOutput = errors.New("unknown log output")
+
+// openOutputs opens the outputs of the logger, entries are written to stderr when there are none.
+func openOutputs(cfg config.Logger) (io.Writer, error) {
+	writers := make([]io.Writer, 0, len(cfg.Outputs))
+
+	for _, output := range cfg.Outputs {
+		switch output {
+		case OutputStderr:
+			writers = append(writers, os.Stderr)
+		case OutputStdout:
+			writers = append(writers, os.Stdout)
+		case OutputFile:
+			writers = append(writers, &lumberjack.Logger{
+				Filename:   cfg.File.Path,
+				MaxSize:    cfg.File.MaxSize,
+				MaxBackups: cfg.File.MaxBackups,
+				MaxAge:     cfg.File.MaxAge,
+				Compress:   cfg.File.Compress,
+			})
+		case OutputSyslog:
+			// entries are formatted before they are sent, so their level is in the message.
+			w, err := syslog.Dial(cfg.Syslog.Network, cfg.Syslog.Address, syslog.LOG_INFO|syslog.LOG_DAEMON, cfg.Syslog.Tag)
+			if err != nil {
+				return nil, fmt.Errorf("failed to connect to syslog: %w", err)
+			}
+
+			writers = append(writers, w)
+		default:
+			return nil, fmt.Errorf("%w: %s", ErrUnknownOutput, output)
+		}
+	}
+
+	if len(writers) == 0 {
+		return os.Stderr, nil
+	}
+
+	return skipEmpty{io.MultiWriter(writers...)}, nil
+}
+
+// skipEmpty skips the empty writes of filtered entries, which would be empty messages on syslog.
+type skipEmpty struct {
+	io.Writer
+}
+
+func (s skipEmpty) Write(p []byte) (int, error) {
+	if len(p) == 0 {
+		return 0, nil
+	}
+
+	return s.Writer.Write(p)
+}
@@ -0,0 +1,17 @@
+create table if not exists suppressions
+(
+    id              uuid        PRIMARY KEY,
+    account_id      uuid,
+    recipient_hmac  TEXT        not null CHECK (recipient_hmac <> ''),
+    source          VARCHAR(16) not null CHECK (source <> ''),
+    created_at      timestamp   not null default now(),
+    constraint fk_accounts
+        foreign key(account_id)
+            references accounts(id)
+);
+
+-- account_id is null for global entries which apply to every account.
+create unique index if not exists suppressions_global_recipient_idx
+    on suppressions(recipient_hmac) where account_id is null;
+create unique index if not exists suppressions_account_recipient_idx
+    on suppressions(account_id, recipient_hmac) where account_id is not null;
@@ -0,0 +1,2 @@
+-- accounts in sandbox mode can only send to the white listed recipients.
+alter table accounts add column if not exists sandbox boolean not null default false;
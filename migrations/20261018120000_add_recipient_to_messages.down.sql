@@ -0,0 +1 @@
+alter table messages drop column if exists recipient;
\ No newline at end of file
@@ -0,0 +1,2 @@
+-- recipient is the normalized phone number in E.164 format.
+alter table messages add column if not exists recipient TEXT not null default '';
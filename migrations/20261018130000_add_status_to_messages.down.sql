@@ -0,0 +1,3 @@
+drop index if exists messages_user_id_created_at_idx;
+create index if not exists messages_users_idx on users(id);
+alter table messages drop column if exists status;
@@ -0,0 +1,7 @@
+alter table messages add column if not exists status VARCHAR(20) not null default 'accepted';
+
+-- the index of the create messages migration was mistakenly created on users.
+drop index if exists messages_users_idx;
+
+-- serves listing the messages of a user, paginated by (created_at, id).
+create index if not exists messages_user_id_created_at_idx on messages(user_id, created_at, id);
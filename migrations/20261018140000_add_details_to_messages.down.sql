@@ -0,0 +1,6 @@
+alter table messages drop column if exists metadata;
+alter table messages drop column if exists client_reference;
+alter table messages drop column if exists provider;
+alter table messages drop column if exists price;
+alter table messages drop column if exists segments;
+alter table messages drop column if exists sender_id;
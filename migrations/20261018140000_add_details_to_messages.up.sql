@@ -0,0 +1,7 @@
+alter table messages add column if not exists sender_id VARCHAR(20) not null default '';
+-- segments is the number of SMS parts the payload is sent in.
+alter table messages add column if not exists segments INTEGER not null default 1;
+alter table messages add column if not exists price BIGINT not null default 0;
+alter table messages add column if not exists provider VARCHAR(50) not null default '';
+alter table messages add column if not exists client_reference VARCHAR(100) not null default '';
+alter table messages add column if not exists metadata JSONB not null default '{}';
@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS search_audits;
+drop index if exists users_account_id_idx;
+drop index if exists messages_created_at_idx;
+drop index if exists messages_recipient_idx;
+drop index if exists messages_payload_trgm_idx;
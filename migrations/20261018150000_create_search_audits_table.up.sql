@@ -0,0 +1,19 @@
+create extension if not exists pg_trgm;
+
+-- serve the admin message search, payload substrings are matched by trigrams.
+create index if not exists messages_payload_trgm_idx on messages using gin (payload gin_trgm_ops);
+create index if not exists messages_recipient_idx on messages(recipient);
+create index if not exists messages_created_at_idx on messages(created_at, id);
+create index if not exists users_account_id_idx on users(account_id);
+
+create table if not exists search_audits
+(
+    id              uuid        PRIMARY KEY,
+    actor           TEXT        not null CHECK (actor <> ''),
+    remote_address  TEXT        not null default '',
+    query           JSONB       not null,
+    results         INTEGER     not null,
+    created_at      timestamp   not null default now()
+);
+
+create index if not exists search_audits_created_at_idx on search_audits(created_at);
@@ -0,0 +1,4 @@
+drop index if exists messages_plaintext_idx;
+drop index if exists messages_recipient_hmac_idx;
+alter table messages drop column if exists recipient_hmac;
+alter table messages drop column if exists encrypted;
@@ -0,0 +1,8 @@
+-- encrypted indicates that payload and recipient are stored encrypted,
+-- recipient_hmac is the blind index to look up encrypted recipients.
+alter table messages add column if not exists encrypted BOOLEAN not null default false;
+alter table messages add column if not exists recipient_hmac TEXT not null default '';
+
+create index if not exists messages_recipient_hmac_idx on messages(recipient_hmac);
+-- serves the encrypt-messages command, which encrypts plaintext rows in batches.
+create index if not exists messages_plaintext_idx on messages(id) where not encrypted;
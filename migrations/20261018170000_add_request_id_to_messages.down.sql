@@ -0,0 +1 @@
+alter table messages drop column if exists request_id;
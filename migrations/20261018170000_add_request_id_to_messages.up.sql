@@ -0,0 +1,2 @@
+-- request_id is the X-Request-ID of the request which sent the message, to correlate it with logs.
+alter table messages add column if not exists request_id VARCHAR(64) not null default '';
@@ -0,0 +1,11 @@
+-- recipients accounts in sandbox mode can send to, in E.164 format.
+create table if not exists whitelisted_numbers
+(
+    account_id  uuid        not null,
+    recipient   VARCHAR(16) not null CHECK (recipient <> ''),
+    created_at  timestamp   not null default now(),
+    primary key (account_id, recipient),
+    constraint fk_accounts
+        foreign key(account_id)
+            references accounts(id)
+);
@@ -8,35 +8,44 @@ import (
 	"github.com/lib/pq"
 )
 
+// balanceCheck is the check constraint keeping accounts' balance non-negative.
+const balanceCheck = "accounts_balance_check"
+
 var (
 	// ErrRecordNotFound indicates that specified record was not found.
 	ErrRecordNotFound = errors.New("record not found")
 	// ErrDuplicateEntry indicates that duplicate entry for this key exists.
 	ErrDuplicateEntry = errors.New("record already exists")
+	// ErrInsufficientBalance indicates that the account's balance is less than the debited amount.
+	ErrInsufficientBalance = errors.New("insufficient balance")
 	// ErrUnknown indicates an unknown error occurred at model.
 	ErrUnknown = errors.New("unknown model error")
 )
 
-func parseError(err error) error {
+// ParseError converts errors of the database to the errors of the model,
+// keeping the original error in the chain.
+func ParseError(err error) error {
 	if err == nil {
 		return nil
 	}
 
 	if errors.Is(err, gorm.ErrRecordNotFound) {
-		return fmt.Errorf("%w: %s", ErrRecordNotFound, err.Error())
+		return fmt.Errorf("%w: %w", ErrRecordNotFound, err)
 	}
 
-	pqErr, typeOK := err.(*pq.Error)
+	var pqErr *pq.Error
 
-	if !typeOK {
-		return fmt.Errorf("%w: failed to cast error to pq.Error: %s", ErrUnknown, err.Error())
+	if !errors.As(err, &pqErr) {
+		return fmt.Errorf("%w: %w", ErrUnknown, err)
 	}
 
 	// reference: https://www.postgresql.org/docs/current/errcodes-appendix.html
-	switch pqErr.Code {
-	case "23505":
-		return fmt.Errorf("%w: %s", ErrDuplicateEntry, err.Error())
+	switch {
+	case pqErr.Code == "23505":
+		return fmt.Errorf("%w: %w", ErrDuplicateEntry, err)
+	case pqErr.Code == "23514" && pqErr.Constraint == balanceCheck:
+		return fmt.Errorf("%w: %w", ErrInsufficientBalance, err)
 	default:
-		return fmt.Errorf("%w: undefined pq error: %s", ErrUnknown, err.Error())
+		return fmt.Errorf("%w: undefined pq error: %w", ErrUnknown, err)
 	}
 }
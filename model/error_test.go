@@ -0,0 +1,40 @@
+package model
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/jinzhu/gorm"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseError(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name     string
+		err      error
+		expected error
+	}{
+		{name: "not found", err: gorm.ErrRecordNotFound, expected: ErrRecordNotFound},
+		{name: "duplicate", err: &pq.Error{Code: "23505"}, expected: ErrDuplicateEntry},
+		{name: "balance", err: &pq.Error{Code: "23514", Constraint: balanceCheck}, expected: ErrInsufficientBalance},
+		{name: "other check", err: &pq.Error{Code: "23514", Constraint: "users_name_check"}, expected: ErrUnknown},
+		{name: "not pq", err: context.Canceled, expected: ErrUnknown},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			err := ParseError(tc.err)
+
+			require.ErrorIs(t, err, tc.expected)
+			require.True(t, errors.Is(err, tc.err) || errors.As(err, new(*pq.Error)), "the original error should be kept")
+		})
+	}
+
+	require.NoError(t, ParseError(nil))
+}
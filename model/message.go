@@ -1,10 +1,81 @@
 package model
 
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// MessageStatusAccepted is the status of a message accepted for sending.
+// Delivery reports move messages to one of the final statuses.
+const (
+	MessageStatusAccepted  = "accepted"
+	MessageStatusDelivered = "delivered"
+	MessageStatusFailed    = "failed"
+	MessageStatusExpired   = "expired"
+)
+
+// IsFinalStatus reports whether a message with the status will not change status anymore.
+func IsFinalStatus(status string) bool {
+	return status == MessageStatusDelivered || status == MessageStatusFailed || status == MessageStatusExpired
+}
+
 type Message struct {
-	ID       string
-	UserID   string
-	Payload  string
-	Language string
+	ID     string `json:"id"`
+	UserID string `json:"user_id"`
+	// Recipient is the normalized phone number in E.164 format.
+	Recipient string `json:"recipient"`
+	// RecipientHMAC is the blind index of the recipient, to look it up when it is encrypted.
+	RecipientHMAC string `json:"-"`
+	SenderID      string `json:"sender_id"`
+	Payload       string `json:"payload"`
+	Language      string `json:"language"`
+	Status        string `json:"status"`
+	// Segments is the number of SMS parts the payload is sent in.
+	Segments int `json:"segments"`
+	// Price is the amount debited from the account for the message.
+	Price    int64  `json:"price"`
+	Provider string `json:"provider"`
+	// ClientReference and Metadata are set by the client and returned as is.
+	ClientReference string   `json:"client_reference"`
+	Metadata        Metadata `json:"metadata"`
+	// RequestID is the X-Request-ID of the request which sent the message.
+	RequestID string    `json:"request_id"`
+	CreatedAt time.Time `json:"created_at"`
+	// Encrypted indicates that the payload and recipient are stored encrypted.
+	Encrypted bool `json:"-"`
+}
+
+// Metadata is stored as a JSONB column.
+type Metadata map[string]string
+
+func (m Metadata) Value() (driver.Value, error) {
+	if m == nil {
+		return "{}", nil
+	}
+
+	raw, err := json.Marshal(m)
+	if err != nil {
+		return nil, err
+	}
+
+	return string(raw), nil
+}
+
+func (m *Metadata) Scan(src interface{}) error {
+	switch src := src.(type) {
+	case nil:
+		*m = nil
+
+		return nil
+	case []byte:
+		return json.Unmarshal(src, m)
+	case string:
+		return json.Unmarshal([]byte(src), m)
+	default:
+		return fmt.Errorf("unsupported metadata type %T", src)
+	}
 }
 
 type User struct {
@@ -16,6 +87,7 @@ type User struct {
 type Account struct {
 	ID      string
 	Balance int64
+	Sandbox bool
 }
 
 type Profile struct {
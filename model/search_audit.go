@@ -0,0 +1,13 @@
+package model
+
+import "time"
+
+// SearchAudit records a search of the message history by support staff.
+type SearchAudit struct {
+	ID            string    `json:"id"`
+	Actor         string    `json:"actor"`
+	RemoteAddress string    `json:"remote_address"`
+	Query         string    `json:"query"`
+	Results       int       `json:"results"`
+	CreatedAt     time.Time `json:"created_at"`
+}
@@ -0,0 +1,20 @@
+package model
+
+import "time"
+
+const (
+	// SuppressionSourceAPI indicates the entry was added through the API.
+	SuppressionSourceAPI = "api"
+	// SuppressionSourceInbound indicates the recipient opted out by an inbound keyword.
+	SuppressionSourceInbound = "inbound"
+)
+
+// Suppression is an opt-out list entry. Entries without an AccountID are global.
+// Recipients are kept as HMACs of their normalized phone number, so the list does not hold plain PII.
+type Suppression struct {
+	ID            string    `json:"id"`
+	AccountID     *string   `json:"account_id,omitempty"`
+	RecipientHMAC string    `json:"recipient_hmac"`
+	Source        string    `json:"source"`
+	CreatedAt     time.Time `json:"created_at"`
+}
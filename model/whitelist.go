@@ -0,0 +1,11 @@
+package model
+
+import "time"
+
+// WhitelistedNumber is a recipient an account can send to while it is in sandbox mode.
+// Recipients are in E.164 format.
+type WhitelistedNumber struct {
+	AccountID string    `json:"account_id"`
+	Recipient string    `json:"recipient"`
+	CreatedAt time.Time `json:"created_at"`
+}
@@ -0,0 +1,128 @@
+package health
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	StatusUp       = "up"
+	StatusDown     = "down"
+	StatusDraining = "draining"
+)
+
+// Check reports whether a dependency of the server is usable.
+type Check struct {
+	Name string
+	Func func(ctx context.Context) error
+}
+
+// Component is the result of a single check.
+type Component struct {
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+// Report is the response of the readiness probe.
+type Report struct {
+	Status     string               `json:"status"`
+	Components map[string]Component `json:"components,omitempty"`
+}
+
+// Readiness runs the checks of a server on each probe.
+// The server stays not-ready once it starts draining for shutdown.
+type Readiness struct {
+	checks   []Check
+	timeout  time.Duration
+	draining atomic.Bool
+}
+
+func NewReadiness(timeout time.Duration, checks ...Check) *Readiness {
+	return &Readiness{
+		checks:  checks,
+		timeout: timeout,
+	}
+}
+
+// Drain marks the server as not-ready, so load balancers stop sending new requests to it.
+func (r *Readiness) Drain() {
+	r.draining.Store(true)
+}
+
+// Check runs all checks concurrently, each one is bounded by the readiness timeout.
+func (r *Readiness) Check(ctx context.Context) Report {
+	if r.draining.Load() {
+		return Report{Status: StatusDraining}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	report := Report{
+		Status:     StatusUp,
+		Components: make(map[string]Component, len(r.checks)),
+	}
+
+	var (
+		mu sync.Mutex
+		wg sync.WaitGroup
+	)
+
+	for _, check := range r.checks {
+		wg.Add(1)
+
+		go func(check Check) {
+			defer wg.Done()
+
+			component := Component{Status: StatusUp}
+
+			if err := check.Func(ctx); err != nil {
+				component = Component{Status: StatusDown, Error: err.Error()}
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+
+			report.Components[check.Name] = component
+
+			if component.Status == StatusDown {
+				report.Status = StatusDown
+			}
+		}(check)
+	}
+
+	wg.Wait()
+
+	return report
+}
+
+// Handler responds with 200 when all checks pass and with 503 otherwise.
+func (r *Readiness) Handler(c echo.Context) error {
+	report := r.Check(c.Request().Context())
+
+	if report.Status != StatusUp {
+		return c.JSON(http.StatusServiceUnavailable, report)
+	}
+
+	return c.JSON(http.StatusOK, report)
+}
+
+// TCP checks that a TCP connection can be established to the given address.
+func TCP(address string) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		var dialer net.Dialer
+
+		conn, err := dialer.DialContext(ctx, "tcp", address)
+		if err != nil {
+			return err
+		}
+
+		return conn.Close()
+	}
+}
@@ -0,0 +1,99 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadiness(t *testing.T) {
+	t.Parallel()
+
+	up := Check{Name: "up", Func: func(context.Context) error { return nil }}
+	down := Check{Name: "down", Func: func(context.Context) error { return errors.New("connection refused") }}
+	slow := Check{Name: "slow", Func: func(ctx context.Context) error {
+		<-ctx.Done()
+
+		return ctx.Err()
+	}}
+
+	tcs := []struct {
+		name           string
+		checks         []Check
+		drain          bool
+		expectedCode   int
+		expectedReport Report
+	}{
+		{
+			name:         "all up",
+			checks:       []Check{up},
+			expectedCode: http.StatusOK,
+			expectedReport: Report{
+				Status:     StatusUp,
+				Components: map[string]Component{"up": {Status: StatusUp}},
+			},
+		},
+		{
+			name:         "one down",
+			checks:       []Check{up, down},
+			expectedCode: http.StatusServiceUnavailable,
+			expectedReport: Report{
+				Status: StatusDown,
+				Components: map[string]Component{
+					"up":   {Status: StatusUp},
+					"down": {Status: StatusDown, Error: "connection refused"},
+				},
+			},
+		},
+		{
+			name:         "timeout",
+			checks:       []Check{slow},
+			expectedCode: http.StatusServiceUnavailable,
+			expectedReport: Report{
+				Status: StatusDown,
+				Components: map[string]Component{
+					"slow": {Status: StatusDown, Error: context.DeadlineExceeded.Error()},
+				},
+			},
+		},
+		{
+			name:           "draining",
+			checks:         []Check{up},
+			drain:          true,
+			expectedCode:   http.StatusServiceUnavailable,
+			expectedReport: Report{Status: StatusDraining},
+		},
+	}
+
+	for i := range tcs {
+		tc := tcs[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			readiness := NewReadiness(10*time.Millisecond, tc.checks...)
+			if tc.drain {
+				readiness.Drain()
+			}
+
+			e := echo.New()
+			e.GET("/readyz", readiness.Handler)
+
+			w := httptest.NewRecorder()
+			e.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+
+			require.Equal(t, tc.expectedCode, w.Code)
+
+			var report Report
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &report))
+			require.Equal(t, tc.expectedReport, report)
+		})
+	}
+}
@@ -2,6 +2,7 @@ package locale
 
 import (
 	"errors"
+	"strings"
 
 	"arvanch/i18n"
 )
@@ -27,14 +28,33 @@ func All() []Locale {
 	return []Locale{FA, AR, EN, KU}
 }
 
+// RegionAll returns the allowed locales of a region from the region registry.
 func RegionAll(region i18n.Region) []Locale {
-	if region == i18n.Arvan {
-		return []Locale{AR, EN, KU}
-	} else if region == i18n.Turkey {
-		return []Locale{FA, EN}
+	info, ok := i18n.Lookup(region)
+	if !ok {
+		return nil
 	}
 
-	return nil
+	locales := make([]Locale, len(info.Locales))
+	for i := range info.Locales {
+		locales[i] = Locale(info.Locales[i])
+	}
+
+	return locales
+}
+
+// Detect returns the locale of a payload based on its script.
+func Detect(payload string) Locale {
+	switch i18n.DetectLanguage(payload) {
+	case i18n.PersianLanguage:
+		return FA
+	case i18n.ArabicLanguage:
+		return AR
+	case i18n.KurdishLanguage:
+		return KU
+	default:
+		return EN
+	}
 }
 
 func Validate(locale Locale) error {
@@ -56,3 +76,22 @@ func ValidateRegion(locale Locale, region i18n.Region) error {
 
 	return ErrUndefinedLocale
 }
+
+// FromAcceptLanguage returns the first supported locale of an Accept-Language header, EN when there is none.
+// Sorani (ckb) is KU, as Kurdish messages are in Sorani.
+func FromAcceptLanguage(header string) Locale {
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimSpace(strings.Split(tag, ";")[0])
+		lang := Locale(strings.ToLower(strings.Split(tag, "-")[0]))
+
+		if lang == "ckb" {
+			return KU
+		}
+
+		if Validate(lang) == nil {
+			return lang
+		}
+	}
+
+	return EN
+}
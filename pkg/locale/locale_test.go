@@ -0,0 +1,145 @@
+package locale
+
+import (
+	"testing"
+
+	"arvanch/i18n"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDetect(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name     string
+		payload  string
+		expected Locale
+	}{
+		{
+			name:     "persian",
+			payload:  "سلام",
+			expected: FA,
+		},
+		{
+			name:     "arabic",
+			payload:  "مرحبا بك",
+			expected: AR,
+		},
+		{
+			name:     "kurdish",
+			payload:  "سڵاو",
+			expected: KU,
+		},
+		{
+			name:     "english",
+			payload:  "hello",
+			expected: EN,
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			require.Equal(t, tt.expected, Detect(tt.payload))
+		})
+	}
+}
+
+func TestValidateRegion(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name    string
+		locale  Locale
+		region  i18n.Region
+		wantErr bool
+	}{
+		{
+			name:   "arabic in arvan",
+			locale: AR,
+			region: i18n.Arvan,
+		},
+		{
+			name:   "persian in turkey",
+			locale: FA,
+			region: i18n.Turkey,
+		},
+		{
+			name:    "persian in arvan",
+			locale:  FA,
+			region:  i18n.Arvan,
+			wantErr: true,
+		},
+		{
+			name:   "arabic in iraq",
+			locale: AR,
+			region: i18n.Region("iraq"),
+		},
+		{
+			name:    "persian in iraq",
+			locale:  FA,
+			region:  i18n.Region("iraq"),
+			wantErr: true,
+		},
+		{
+			name:    "unregistered region",
+			locale:  EN,
+			region:  i18n.Invalid,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			err := ValidateRegion(tt.locale, tt.region)
+			require.Equal(t, tt.wantErr, err != nil)
+		})
+	}
+}
+
+func TestFromAcceptLanguage(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name     string
+		header   string
+		expected Locale
+	}{
+		{
+			name:     "empty",
+			header:   "",
+			expected: EN,
+		},
+		{
+			name:     "region subtag",
+			header:   "fa-IR",
+			expected: FA,
+		},
+		{
+			name:     "first supported",
+			header:   "de-DE;q=0.9, ar;q=0.8, en;q=0.5",
+			expected: AR,
+		},
+		{
+			name:     "sorani",
+			header:   "ckb-IQ",
+			expected: KU,
+		},
+		{
+			name:     "unsupported",
+			header:   "de, fr",
+			expected: EN,
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			require.Equal(t, tt.expected, FromAcceptLanguage(tt.header))
+		})
+	}
+}
@@ -0,0 +1,52 @@
+package mask
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+const (
+	// visibleSuffix is the number of trailing characters of a phone number left visible.
+	visibleSuffix = 4
+	// hidden is the number of characters of a phone number hidden before the visible suffix.
+	hidden = 3
+
+	stars = "***"
+)
+
+// Phone masks a phone number, keeping its prefix and last digits, e.g. 09121234567 is masked as 0912***4567
+// and +989121234567 as +98912***4567. Numbers too short to keep a prefix are fully masked.
+func Phone(number string) string {
+	runes := []rune(number)
+
+	if len(runes) <= visibleSuffix+hidden {
+		return strings.Repeat("*", len(runes))
+	}
+
+	prefix := len(runes) - visibleSuffix - hidden
+
+	return string(runes[:prefix]) + stars + string(runes[len(runes)-visibleSuffix:])
+}
+
+// JSONFields masks the phone numbers in the given top level string fields of a JSON object.
+// Texts which are not JSON objects are returned as is.
+func JSONFields(text string, fields ...string) string {
+	var object map[string]interface{}
+
+	if err := json.Unmarshal([]byte(text), &object); err != nil {
+		return text
+	}
+
+	for _, field := range fields {
+		if value, ok := object[field].(string); ok {
+			object[field] = Phone(value)
+		}
+	}
+
+	masked, err := json.Marshal(object)
+	if err != nil {
+		return text
+	}
+
+	return string(masked)
+}
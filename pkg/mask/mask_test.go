@@ -0,0 +1,40 @@
+package mask
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPhone(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name     string
+		number   string
+		expected string
+	}{
+		{name: "national", number: "09121234567", expected: "0912***4567"},
+		{name: "e164", number: "+989121234567", expected: "+98912***4567"},
+		{name: "short", number: "1234567", expected: "*******"},
+		{name: "empty", number: "", expected: ""},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			require.Equal(t, tc.expected, Phone(tc.number))
+		})
+	}
+}
+
+func TestJSONFields(t *testing.T) {
+	t.Parallel()
+
+	require.JSONEq(t,
+		`{"phone_number": "0912***4567", "payload": "hello", "count": 1}`,
+		JSONFields(`{"phone_number": "09121234567", "payload": "hello", "count": 1}`, "phone_number", "sender"),
+	)
+	require.Equal(t, "not json", JSONFields("not json", "phone_number"))
+}
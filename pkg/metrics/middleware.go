@@ -0,0 +1,53 @@
+package metrics
+
+import (
+	"strconv"
+	"time"
+
+	"arvanch/config"
+
+	"github.com/labstack/echo/v4"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+const (
+	LabelServer = "server"
+	LabelMethod = "method"
+	LabelRoute  = "route"
+	LabelStatus = "status"
+)
+
+// nolint:gochecknoglobals
+var httpDuration = promauto.NewHistogramVec(
+	prometheus.HistogramOpts{
+		Namespace: config.Namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "duration of http requests per route and status code",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{LabelServer, LabelMethod, LabelRoute, LabelStatus},
+)
+
+// HTTPMetrics reports duration of requests per route and response status code.
+func HTTPMetrics(server string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			startTime := time.Now()
+
+			err := next(c)
+			if err != nil {
+				// let the error handler write the response, so its status code is reported.
+				c.Error(err)
+			}
+
+			httpDuration.With(prometheus.Labels{
+				LabelServer: server,
+				LabelMethod: c.Request().Method,
+				LabelRoute:  c.Path(),
+				LabelStatus: strconv.Itoa(c.Response().Status),
+			}).Observe(time.Since(startTime).Seconds())
+
+			return err
+		}
+	}
+}
@@ -0,0 +1,37 @@
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHTTPMetrics(t *testing.T) {
+	e := echo.New()
+	e.Use(HTTPMetrics("test"))
+
+	e.GET("/users/:id", func(c echo.Context) error { return c.NoContent(http.StatusNoContent) })
+	e.GET("/fail", func(c echo.Context) error { return echo.NewHTTPError(http.StatusConflict, "conflict") })
+
+	for _, url := range []string{"/users/1", "/users/2", "/fail"} {
+		w := httptest.NewRecorder()
+		e.ServeHTTP(w, httptest.NewRequest(http.MethodGet, url, nil))
+	}
+
+	count := func(route, status string) uint64 {
+		var m dto.Metric
+
+		observer := httpDuration.WithLabelValues("test", http.MethodGet, route, status)
+		require.NoError(t, observer.(prometheus.Metric).Write(&m))
+
+		return m.GetHistogram().GetSampleCount()
+	}
+
+	require.EqualValues(t, 2, count("/users/:id", "204"))
+	require.EqualValues(t, 1, count("/fail", "409"))
+}
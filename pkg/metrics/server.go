@@ -0,0 +1,39 @@
+package metrics
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"arvanch/config"
+
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/sirupsen/logrus"
+)
+
+const readHeaderTimeout = 5 * time.Second
+
+// StartServer starts serving prometheus metrics on /metrics in the background.
+// It returns nil when prometheus is disabled.
+func StartServer(cfg config.Prometheus) *http.Server {
+	if !cfg.Enabled {
+		return nil
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.Handler())
+
+	srv := &http.Server{
+		Addr:              cfg.Address,
+		Handler:           mux,
+		ReadHeaderTimeout: readHeaderTimeout,
+	}
+
+	go func() {
+		if err := srv.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) && err != nil {
+			logrus.Errorf("metrics server failed: %s", err.Error())
+		}
+	}()
+
+	return srv
+}
@@ -26,12 +26,15 @@ func (o *OverLimitError) Error() string {
 
 type Evaluator interface {
 	EvaluateWithWaitTime(hits int64) (bool, time.Duration, error)
+	// Name identifies the evaluated rule in metrics.
+	Name() string
 }
 
 type GubernatorEvaluator struct {
 	*GubernatorLimiter
-	key string
-	cfg *config.RateLimitRule
+	key     string
+	cfg     *config.RateLimitRule
+	metrics Metrics
 }
 
 type GubernatorLimiter struct {
@@ -63,9 +66,15 @@ func NewGubernatorEvaluator(limiter *GubernatorLimiter,
 		GubernatorLimiter: limiter,
 		key:               key,
 		cfg:               rule,
+		metrics:           metrics,
 	}, nil
 }
 
+// Name returns the rule name and key of the evaluator.
+func (l *GubernatorEvaluator) Name() string {
+	return fmt.Sprintf("%s:%s", l.cfg.Name, l.key)
+}
+
 // EvaluateWithWaitTime is used to evaluate request for specific rate limit.
 // hit specifies how much this call costs (e.g. 10 from overall 340 limits).
 // it also returns the wait time until the caller can try again.
@@ -78,18 +87,23 @@ func (l *GubernatorEvaluator) EvaluateWithWaitTime(hits int64) (bool, time.Durat
 		return false, 0, fmt.Errorf("evaluate failed: %w", err)
 	}
 
-	if resp.GetStatus() == gubernator.Status_UNDER_LIMIT {
+	allowed := resp.GetStatus() == gubernator.Status_UNDER_LIMIT
+
+	l.metrics.reportDecision(allowed, l.Name())
+
+	if allowed {
 		return true, 0, nil
-	} else {
-		resetTime := time.Until(time.Unix(0, resp.GetResetTime()*int64(time.Millisecond)))
-		return false, resetTime, nil
 	}
+
+	resetTime := time.Until(time.Unix(0, resp.GetResetTime()*int64(time.Millisecond)))
+
+	return false, resetTime, nil
 }
 
 func (l *GubernatorEvaluator) call(hits int64) (_ *gubernator.RateLimitResp, finalErr error) {
 	startTime := time.Now()
 
-	defer func() { metrics.report(finalErr, startTime, fmt.Sprintf("%s:%s", l.cfg.Name, l.key)) }()
+	defer func() { l.metrics.report(finalErr, startTime, l.Name()) }()
 
 	rateLimitReq := gubernator.RateLimitReq{
 		Name:      l.cfg.Name,
@@ -119,3 +133,18 @@ func (l *GubernatorEvaluator) call(hits int64) (_ *gubernator.RateLimitResp, fin
 
 	return resp.GetResponses()[0], nil
 }
+
+// HealthCheck checks that the gubernator cluster is reachable and healthy.
+// It can be used as a readiness check of the servers that rate limit requests.
+func (l *GubernatorLimiter) HealthCheck(ctx context.Context) error {
+	resp, err := l.Client.HealthCheck(ctx, &gubernator.HealthCheckReq{})
+	if err != nil {
+		return fmt.Errorf("could not reach gubernator: %w", err)
+	}
+
+	if resp.GetStatus() != gubernator.Healthy {
+		return fmt.Errorf("gubernator is %s: %s", resp.GetStatus(), resp.GetMessage())
+	}
+
+	return nil
+}
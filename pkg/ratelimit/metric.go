@@ -4,40 +4,84 @@ import (
 	"time"
 
 	"arvanch/config"
+
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promauto"
 )
 
 // Metrics represents prometheus metrics for models.
 type Metrics struct {
-	ErrCounter *prometheus.CounterVec
-	Histogram  *prometheus.HistogramVec
+	ErrCounter      *prometheus.CounterVec
+	Histogram       *prometheus.HistogramVec
+	DecisionCounter *prometheus.CounterVec
+	FailOpenCounter *prometheus.CounterVec
 }
 
-const LabelRuleName = "rule_name"
+const (
+	LabelRuleName = "rule_name"
+	LabelDecision = "decision"
+
+	DecisionUnderLimit = "under_limit"
+	DecisionOverLimit  = "over_limit"
+)
 
 // nolint:gochecknoglobals
-var (
-	metrics = Metrics{
-		ErrCounter: promauto.NewCounterVec(
+var metrics = NewMetrics(prometheus.DefaultRegisterer)
+
+// NewMetrics creates ratelimit metrics and registers them in the given registerer.
+func NewMetrics(reg prometheus.Registerer) Metrics {
+	factory := promauto.With(reg)
+
+	return Metrics{
+		ErrCounter: factory.NewCounterVec(
 			prometheus.CounterOpts{
 				Namespace: config.Namespace,
 				Name:      "gubernator_client_err_total",
+				Help:      "number of failed calls to gubernator",
 			}, []string{LabelRuleName},
 		),
-		Histogram: promauto.NewHistogramVec(
+		Histogram: factory.NewHistogramVec(
 			prometheus.HistogramOpts{
 				Namespace: config.Namespace,
-				Name:      "gubernator_client_duration_total",
+				Name:      "gubernator_client_duration_seconds",
+				Help:      "duration of calls to gubernator",
+				Buckets:   prometheus.DefBuckets,
+			}, []string{LabelRuleName},
+		),
+		DecisionCounter: factory.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: config.Namespace,
+				Name:      "ratelimit_decisions_total",
+				Help:      "number of rate limit decisions per rule",
+			}, []string{LabelRuleName, LabelDecision},
+		),
+		FailOpenCounter: factory.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: config.Namespace,
+				Name:      "ratelimit_fail_open_total",
+				Help:      "number of requests let through because the rate limit evaluation failed",
 			}, []string{LabelRuleName},
 		),
 	}
-)
+}
 
 func (m Metrics) report(err error, startTime time.Time, ruleName string) {
 	if err != nil {
-		m.ErrCounter.With(prometheus.Labels{LabelRuleName: ruleName})
+		m.ErrCounter.With(prometheus.Labels{LabelRuleName: ruleName}).Inc()
 	}
 
 	m.Histogram.With(prometheus.Labels{LabelRuleName: ruleName}).Observe(time.Since(startTime).Seconds())
 }
+
+func (m Metrics) reportDecision(allowed bool, ruleName string) {
+	decision := DecisionUnderLimit
+	if !allowed {
+		decision = DecisionOverLimit
+	}
+
+	m.DecisionCounter.With(prometheus.Labels{LabelRuleName: ruleName, LabelDecision: decision}).Inc()
+}
+
+func (m Metrics) reportFailOpen(ruleName string) {
+	m.FailOpenCounter.With(prometheus.Labels{LabelRuleName: ruleName}).Inc()
+}
@@ -0,0 +1,118 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"arvanch/config"
+
+	gubernator "github.com/gubernator-io/gubernator/v2"
+	"github.com/labstack/echo/v4"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+)
+
+type fakeClient struct {
+	gubernator.V1Client
+	statuses []gubernator.Status
+	err      error
+}
+
+func (f *fakeClient) GetRateLimits(
+	_ context.Context, _ *gubernator.GetRateLimitsReq, _ ...grpc.CallOption,
+) (*gubernator.GetRateLimitsResp, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+
+	status := f.statuses[0]
+	f.statuses = f.statuses[1:]
+
+	return &gubernator.GetRateLimitsResp{
+		Responses: []*gubernator.RateLimitResp{{Status: status}},
+	}, nil
+}
+
+func newTestEvaluator(t *testing.T, client gubernator.V1Client, m Metrics) *GubernatorEvaluator {
+	t.Helper()
+
+	evaluator, err := NewGubernatorEvaluator(
+		&GubernatorLimiter{Client: client, Timeout: time.Second},
+		&config.RateLimitRule{Name: "bulk", Duration: time.Second, Limit: 1},
+		"user",
+	)
+	require.NoError(t, err)
+
+	evaluator.metrics = m
+
+	return evaluator
+}
+
+func TestMetricsDecisions(t *testing.T) {
+	t.Parallel()
+
+	reg := prometheus.NewRegistry()
+
+	evaluator := newTestEvaluator(t, &fakeClient{statuses: []gubernator.Status{
+		gubernator.Status_UNDER_LIMIT,
+		gubernator.Status_UNDER_LIMIT,
+		gubernator.Status_OVER_LIMIT,
+	}}, NewMetrics(reg))
+
+	for range 3 {
+		_, _, err := evaluator.EvaluateWithWaitTime(1)
+		require.NoError(t, err)
+	}
+
+	expected := `
+# HELP arvanch_ratelimit_decisions_total number of rate limit decisions per rule
+# TYPE arvanch_ratelimit_decisions_total counter
+arvanch_ratelimit_decisions_total{decision="over_limit",rule_name="bulk:user"} 1
+arvanch_ratelimit_decisions_total{decision="under_limit",rule_name="bulk:user"} 2
+`
+
+	require.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(expected), "arvanch_ratelimit_decisions_total"))
+	require.Equal(t, 0, testutil.CollectAndCount(reg, "arvanch_gubernator_client_err_total"))
+	require.Equal(t, 1, testutil.CollectAndCount(reg, "arvanch_gubernator_client_duration_seconds"))
+}
+
+func TestMetricsFailOpen(t *testing.T) {
+	t.Parallel()
+
+	reg := prometheus.NewRegistry()
+	m := NewMetrics(reg)
+
+	evaluator := newTestEvaluator(t, &fakeClient{err: errors.New("connection refused")}, m)
+
+	mw := NewRateLimiterMiddleware(evaluator, DefaultMiddlewareHit, true, true)
+	mw.metrics = m
+
+	e := echo.New()
+	e.GET("/", func(c echo.Context) error { return c.NoContent(http.StatusNoContent) }, mw.CheckLimit())
+
+	for range 2 {
+		w := httptest.NewRecorder()
+		e.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+		require.Equal(t, http.StatusNoContent, w.Code)
+	}
+
+	expected := `
+# HELP arvanch_gubernator_client_err_total number of failed calls to gubernator
+# TYPE arvanch_gubernator_client_err_total counter
+arvanch_gubernator_client_err_total{rule_name="bulk:user"} 2
+# HELP arvanch_ratelimit_fail_open_total number of requests let through because the rate limit evaluation failed
+# TYPE arvanch_ratelimit_fail_open_total counter
+arvanch_ratelimit_fail_open_total{rule_name="bulk:user"} 2
+`
+
+	require.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(expected),
+		"arvanch_gubernator_client_err_total", "arvanch_ratelimit_fail_open_total"))
+	require.Equal(t, 0, testutil.CollectAndCount(reg, "arvanch_ratelimit_decisions_total"))
+}
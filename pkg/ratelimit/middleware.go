@@ -20,6 +20,7 @@ type RateLimiterMiddleware struct {
 	hitValue        int64
 	enableRetryHint bool
 	failOpen        bool
+	metrics         Metrics
 }
 
 func NewRateLimiterMiddleware(
@@ -30,6 +31,7 @@ func NewRateLimiterMiddleware(
 		hitValue:        hitValue,
 		enableRetryHint: enableRetryHint,
 		failOpen:        failOpen,
+		metrics:         metrics,
 	}
 }
 
@@ -41,6 +43,8 @@ func (rl *RateLimiterMiddleware) CheckLimit() echo.MiddlewareFunc {
 				logrus.Errorf("rate limit middleware failed with error: %s", err.Error())
 
 				if rl.failOpen {
+					rl.metrics.reportFailOpen(rl.evaluator.Name())
+
 					return next(c)
 				}
 
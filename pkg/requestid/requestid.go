@@ -0,0 +1,48 @@
+package requestid
+
+import (
+	"context"
+	"regexp"
+
+	"arvanch/log"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/sirupsen/logrus"
+)
+
+// valid matches request IDs accepted from callers, others are replaced with a generated one.
+var valid = regexp.MustCompile(`^[A-Za-z0-9._:-]{1,64}$`)
+
+type key struct{}
+
+// Middleware accepts the X-Request-ID of the caller or generates one, returns it in the response
+// and adds it to the request's context and to the fields of its logs.
+func Middleware() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			req := c.Request()
+
+			id := req.Header.Get(echo.HeaderXRequestID)
+			if !valid.MatchString(id) {
+				id = uuid.New().String()
+			}
+
+			c.Response().Header().Set(echo.HeaderXRequestID, id)
+
+			ctx := context.WithValue(req.Context(), key{}, id)
+			ctx = log.WithFields(ctx, logrus.Fields{"request_id": id})
+
+			c.SetRequest(req.WithContext(ctx))
+
+			return next(c)
+		}
+	}
+}
+
+// FromContext returns the request ID of the request's context, or an empty string when there is none.
+func FromContext(ctx context.Context) string {
+	id, _ := ctx.Value(key{}).(string)
+
+	return id
+}
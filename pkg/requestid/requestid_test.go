@@ -0,0 +1,64 @@
+package requestid
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"arvanch/log"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMiddleware(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name      string
+		header    string
+		generated bool
+	}{
+		{name: "accepted", header: "req-1234"},
+		{name: "missing", generated: true},
+		{name: "too long", header: strings.Repeat("a", 65), generated: true},
+		{name: "invalid characters", header: "req\n1234", generated: true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			var fromContext, logged string
+
+			e := echo.New()
+			e.Use(Middleware())
+			e.GET("/", func(c echo.Context) error {
+				fromContext = FromContext(c.Request().Context())
+				logged, _ = log.FromContext(c.Request().Context()).Data["request_id"].(string)
+
+				return c.NoContent(http.StatusOK)
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.Header.Set(echo.HeaderXRequestID, tc.header)
+
+			w := httptest.NewRecorder()
+			e.ServeHTTP(w, req)
+
+			id := w.Header().Get(echo.HeaderXRequestID)
+
+			if tc.generated {
+				_, err := uuid.Parse(id)
+				require.NoError(t, err)
+			} else {
+				require.Equal(t, tc.header, id)
+			}
+
+			require.Equal(t, id, fromContext)
+			require.Equal(t, id, logged)
+		})
+	}
+}
@@ -6,9 +6,12 @@ import (
 	"crypto/rand"
 	"crypto/sha256"
 	"encoding/hex"
+	"errors"
 	"io"
 )
 
+var ErrInvalidCiphertext = errors.New("ciphertext is too short")
+
 type AESTransformer struct {
 	aead cipher.AEAD
 }
@@ -53,6 +56,9 @@ func (a *AESTransformer) Decrypt(encryptedString string) (string, error) {
 	}
 
 	nonceSize := a.aead.NonceSize()
+	if len(enc) < nonceSize {
+		return "", ErrInvalidCiphertext
+	}
 
 	nonce, ciphertext := enc[:nonceSize], enc[nonceSize:]
 
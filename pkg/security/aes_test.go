@@ -68,3 +68,14 @@ func TestAES(t *testing.T) {
 		})
 	}
 }
+
+func TestAESDecryptInvalid(t *testing.T) {
+	decryptor, err := NewAESTransformer("secret")
+	assert.NoError(t, err)
+
+	_, err = decryptor.Decrypt("00ff")
+	assert.ErrorIs(t, err, ErrInvalidCiphertext)
+
+	_, err = decryptor.Decrypt("not hex")
+	assert.Error(t, err)
+}
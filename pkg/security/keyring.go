@@ -0,0 +1,199 @@
+package security
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"arvanch/config"
+)
+
+const (
+	// keyIDSeparator separates the key ID from the ciphertext or HMAC, it is in neither hex nor base64 alphabets.
+	keyIDSeparator = ":"
+
+	// defaultSecret is the key the default configuration used to ship, it is public.
+	defaultSecret = "secret"
+)
+
+var (
+	ErrInvalidKeyring = errors.New("invalid keyring")
+	ErrUnknownKey     = errors.New("unknown key")
+	ErrInsecureKey    = errors.New("insecure key")
+)
+
+// AESKeyring encrypts with its active key and decrypts with any of its keys.
+// Ciphertexts are prefixed with the ID of their key, ciphertexts without an ID,
+// which were encrypted before the keyring, are decrypted by trying all keys.
+type AESKeyring struct {
+	active string
+	keys   map[string]*AESTransformer
+	// ids is used to try keys in a stable order.
+	ids []string
+}
+
+func NewAESKeyring(cfg config.Keyring) (*AESKeyring, error) {
+	if err := validateKeyring(cfg); err != nil {
+		return nil, err
+	}
+
+	keyring := &AESKeyring{
+		active: cfg.Active,
+		keys:   make(map[string]*AESTransformer, len(cfg.Keys)),
+		ids:    sortedIDs(cfg),
+	}
+
+	for id, key := range cfg.Keys {
+		tr, err := NewAESTransformer(key)
+		if err != nil {
+			return nil, err
+		}
+
+		keyring.keys[id] = tr
+	}
+
+	return keyring, nil
+}
+
+func (k *AESKeyring) Transform(text string) (string, error) {
+	return k.Encrypt(text)
+}
+
+func (k *AESKeyring) Encrypt(text string) (string, error) {
+	ciphertext, err := k.keys[k.active].Encrypt(text)
+	if err != nil {
+		return "", err
+	}
+
+	return k.active + keyIDSeparator + ciphertext, nil
+}
+
+func (k *AESKeyring) Decrypt(text string) (string, error) {
+	id, ciphertext, found := strings.Cut(text, keyIDSeparator)
+	if found {
+		key, ok := k.keys[id]
+		if !ok {
+			return "", fmt.Errorf("%w: %s", ErrUnknownKey, id)
+		}
+
+		return key.Decrypt(ciphertext)
+	}
+
+	var err error
+
+	for _, id := range k.ids {
+		var plaintext string
+
+		if plaintext, err = k.keys[id].Decrypt(text); err == nil {
+			return plaintext, nil
+		}
+	}
+
+	return "", err
+}
+
+// ActivePrefix returns the prefix of texts encrypted with the active key.
+func (k *AESKeyring) ActivePrefix() string {
+	return k.active + keyIDSeparator
+}
+
+// HMACKeyring calculates HMACs with its active key, prefixed with the key ID.
+// As HMACs can not be recalculated without the original text, lookups by HMAC
+// should use Candidates to match values calculated with any of the keys.
+type HMACKeyring struct {
+	active string
+	keys   map[string]*HMACTransformer
+	ids    []string
+}
+
+func NewHMACKeyring(cfg config.Keyring) (*HMACKeyring, error) {
+	if err := validateKeyring(cfg); err != nil {
+		return nil, err
+	}
+
+	keyring := &HMACKeyring{
+		active: cfg.Active,
+		keys:   make(map[string]*HMACTransformer, len(cfg.Keys)),
+		ids:    sortedIDs(cfg),
+	}
+
+	for id, key := range cfg.Keys {
+		keyring.keys[id] = NewHMACTransformer(key)
+	}
+
+	return keyring, nil
+}
+
+func (k *HMACKeyring) Transform(text string) (string, error) {
+	mac, err := k.keys[k.active].Transform(text)
+	if err != nil {
+		return "", err
+	}
+
+	return k.active + keyIDSeparator + mac, nil
+}
+
+// Candidates returns the HMACs of the text with all keys, both with and without key ID
+// so values calculated before the keyring are matched too.
+func (k *HMACKeyring) Candidates(text string) ([]string, error) {
+	candidates := make([]string, 0, 2*len(k.ids))
+
+	for _, id := range k.ids {
+		mac, err := k.keys[id].Transform(text)
+		if err != nil {
+			return nil, err
+		}
+
+		candidates = append(candidates, id+keyIDSeparator+mac, mac)
+	}
+
+	return candidates, nil
+}
+
+func validateKeyring(cfg config.Keyring) error {
+	if len(cfg.Keys) == 0 {
+		return fmt.Errorf("%w: no keys are configured", ErrInvalidKeyring)
+	}
+
+	if _, ok := cfg.Keys[cfg.Active]; !ok {
+		return fmt.Errorf("%w: active key %q is not in keys", ErrInvalidKeyring, cfg.Active)
+	}
+
+	for id, key := range cfg.Keys {
+		if id == "" || strings.Contains(id, keyIDSeparator) {
+			return fmt.Errorf("%w: key ID %q should be non-empty and without %q", ErrInvalidKeyring, id, keyIDSeparator)
+		}
+
+		if err := CheckKey(key); err != nil {
+			return fmt.Errorf("%w: key %q", err, id)
+		}
+	}
+
+	return nil
+}
+
+// CheckKey returns ErrInsecureKey when the key is empty or the key the default configuration used to ship.
+func CheckKey(key string) error {
+	if key == "" || key == defaultSecret {
+		return fmt.Errorf("%w: key is empty or the former default key", ErrInsecureKey)
+	}
+
+	return nil
+}
+
+// sortedIDs returns key IDs with the active one first, so data encrypted before the keyring
+// is most likely decrypted on the first try when the old key is kept active.
+func sortedIDs(cfg config.Keyring) []string {
+	ids := make([]string, 0, len(cfg.Keys))
+
+	for id := range cfg.Keys {
+		if id != cfg.Active {
+			ids = append(ids, id)
+		}
+	}
+
+	sort.Strings(ids)
+
+	return append([]string{cfg.Active}, ids...)
+}
@@ -0,0 +1,121 @@
+package security
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"arvanch/config"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAESKeyring(t *testing.T) {
+	t.Parallel()
+
+	old, err := NewAESKeyring(config.Keyring{Active: "1", Keys: map[string]string{"1": "old"}})
+	require.NoError(t, err)
+
+	rotated, err := NewAESKeyring(config.Keyring{Active: "2", Keys: map[string]string{"1": "old", "2": "new"}})
+	require.NoError(t, err)
+
+	legacy, err := NewAESTransformer("old")
+	require.NoError(t, err)
+
+	text := "Extraordinary Attorney Woo"
+
+	oldCiphertext, err := old.Encrypt(text)
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(oldCiphertext, old.ActivePrefix()))
+
+	newCiphertext, err := rotated.Encrypt(text)
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(newCiphertext, "2:"))
+
+	legacyCiphertext, err := legacy.Encrypt(text)
+	require.NoError(t, err)
+
+	for name, ciphertext := range map[string]string{
+		"old key": oldCiphertext,
+		"new key": newCiphertext,
+		"legacy":  legacyCiphertext,
+	} {
+		plaintext, err := rotated.Decrypt(ciphertext)
+		require.NoError(t, err, name)
+		assert.Equal(t, text, plaintext, name)
+	}
+
+	_, err = old.Decrypt(newCiphertext)
+	assert.True(t, errors.Is(err, ErrUnknownKey))
+}
+
+func TestHMACKeyring(t *testing.T) {
+	t.Parallel()
+
+	old, err := NewHMACKeyring(config.Keyring{Active: "1", Keys: map[string]string{"1": "old"}})
+	require.NoError(t, err)
+
+	rotated, err := NewHMACKeyring(config.Keyring{Active: "2", Keys: map[string]string{"1": "old", "2": "new"}})
+	require.NoError(t, err)
+
+	text := "+989121234567"
+
+	oldMAC, err := old.Transform(text)
+	require.NoError(t, err)
+
+	newMAC, err := rotated.Transform(text)
+	require.NoError(t, err)
+	assert.NotEqual(t, oldMAC, newMAC)
+
+	legacyMAC, err := NewHMACTransformer("old").Transform(text)
+	require.NoError(t, err)
+
+	candidates, err := rotated.Candidates(text)
+	require.NoError(t, err)
+	assert.Equal(t, newMAC, candidates[0], "the active key should come first")
+	assert.Contains(t, candidates, oldMAC)
+	assert.Contains(t, candidates, legacyMAC)
+}
+
+func TestInvalidKeyring(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name     string
+		cfg      config.Keyring
+		expected error
+	}{
+		{name: "no keys", cfg: config.Keyring{Active: "1"}},
+		{name: "active key missing", cfg: config.Keyring{Active: "2", Keys: map[string]string{"1": "key"}}},
+		{name: "empty id", cfg: config.Keyring{Active: "", Keys: map[string]string{"": "key"}}},
+		{name: "separator in id", cfg: config.Keyring{Active: "a:b", Keys: map[string]string{"a:b": "key"}}},
+		{
+			name:     "empty key",
+			cfg:      config.Keyring{Active: "1", Keys: map[string]string{"1": ""}},
+			expected: ErrInsecureKey,
+		},
+		{
+			name:     "former default key",
+			cfg:      config.Keyring{Active: "2", Keys: map[string]string{"1": "secret", "2": "key"}},
+			expected: ErrInsecureKey,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			expected := tc.expected
+			if expected == nil {
+				expected = ErrInvalidKeyring
+			}
+
+			_, err := NewAESKeyring(tc.cfg)
+			assert.True(t, errors.Is(err, expected))
+
+			_, err = NewHMACKeyring(tc.cfg)
+			assert.True(t, errors.Is(err, expected))
+		})
+	}
+}
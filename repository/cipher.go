@@ -0,0 +1,131 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+
+	"arvanch/config"
+	"arvanch/model"
+	"arvanch/pkg/security"
+)
+
+var (
+	ErrEncryptionDisabled = errors.New("encryption of messages is disabled")
+	ErrMissingKeys        = errors.New("encryption keys are not configured")
+)
+
+// MessageCipher encrypts the payload and recipient of messages before they are stored
+// and decrypts them after they are read, so callers of the repository only see plaintext.
+type MessageCipher struct {
+	enabled bool
+	aes     *security.AESKeyring
+	hmac    *security.HMACKeyring
+}
+
+func NewMessageCipher(cfg config.Encryption) (*MessageCipher, error) {
+	// keys are only needed to read messages encrypted while encryption was enabled,
+	// so deployments which never enabled it don't need them.
+	if !cfg.Enabled && len(cfg.Keys.Keys) == 0 && len(cfg.HMACKeys.Keys) == 0 {
+		return &MessageCipher{}, nil
+	}
+
+	aes, err := security.NewAESKeyring(cfg.Keys)
+	if err != nil {
+		return nil, err
+	}
+
+	hmac, err := security.NewHMACKeyring(cfg.HMACKeys)
+	if err != nil {
+		return nil, err
+	}
+
+	return &MessageCipher{
+		enabled: cfg.Enabled,
+		aes:     aes,
+		hmac:    hmac,
+	}, nil
+}
+
+// seal returns a copy of the message to be stored, the message is stored as is when encryption is disabled.
+func (c *MessageCipher) seal(msg *model.Message) (*model.Message, error) {
+	if !c.enabled {
+		return msg, nil
+	}
+
+	sealed := *msg
+
+	var err error
+
+	if sealed.RecipientHMAC, err = c.recipientHMAC(msg.Recipient); err != nil {
+		return nil, err
+	}
+
+	if sealed.Recipient, err = c.aes.Encrypt(msg.Recipient); err != nil {
+		return nil, fmt.Errorf("failed to encrypt recipient: %w", err)
+	}
+
+	if sealed.Payload, err = c.aes.Encrypt(msg.Payload); err != nil {
+		return nil, fmt.Errorf("failed to encrypt payload: %w", err)
+	}
+
+	sealed.Encrypted = true
+
+	return &sealed, nil
+}
+
+// open decrypts the encrypted messages in place, rows stored before encryption was enabled are left as is.
+func (c *MessageCipher) open(msgs []model.Message) error {
+	for i := range msgs {
+		if !msgs[i].Encrypted {
+			continue
+		}
+
+		if c.aes == nil {
+			return fmt.Errorf("%w: message %s is encrypted", ErrMissingKeys, msgs[i].ID)
+		}
+
+		recipient, err := c.aes.Decrypt(msgs[i].Recipient)
+		if err != nil {
+			return fmt.Errorf("failed to decrypt recipient of message %s: %w", msgs[i].ID, err)
+		}
+
+		payload, err := c.aes.Decrypt(msgs[i].Payload)
+		if err != nil {
+			return fmt.Errorf("failed to decrypt payload of message %s: %w", msgs[i].ID, err)
+		}
+
+		msgs[i].Recipient, msgs[i].Payload, msgs[i].Encrypted = recipient, payload, false
+	}
+
+	return nil
+}
+
+// recipientHMAC returns the blind index of the recipient, or an empty string when encryption is disabled.
+func (c *MessageCipher) recipientHMAC(recipient string) (string, error) {
+	if !c.enabled || recipient == "" {
+		return "", nil
+	}
+
+	mac, err := c.hmac.Transform(recipient)
+	if err != nil {
+		return "", fmt.Errorf("failed to calculate recipient hmac: %w", err)
+	}
+
+	return mac, nil
+}
+
+// recipientHMACs returns the blind indexes of the recipient with all HMAC keys,
+// as messages encrypted before a key rotation are indexed with the old keys.
+// They are returned while keys are configured, so messages encrypted before encryption was disabled still match.
+func (c *MessageCipher) recipientHMACs(recipient string) ([]string, error) {
+	if c.hmac == nil || recipient == "" {
+		return nil, nil
+	}
+
+	macs, err := c.hmac.Candidates(recipient)
+	if err != nil {
+		return nil, fmt.Errorf("failed to calculate recipient hmac: %w", err)
+	}
+
+	return macs, nil
+}
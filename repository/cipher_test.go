@@ -0,0 +1,171 @@
+package repository
+
+import (
+	"testing"
+
+	"arvanch/config"
+	"arvanch/model"
+	"arvanch/pkg/security"
+
+	"github.com/stretchr/testify/require"
+)
+
+func keyring(key string) config.Keyring {
+	return config.Keyring{Active: "1", Keys: map[string]string{"1": key}}
+}
+
+func TestMessageCipher(t *testing.T) {
+	t.Parallel()
+
+	cipher, err := NewMessageCipher(config.Encryption{Enabled: true, Keys: keyring("key"), HMACKeys: keyring("mac")})
+	require.NoError(t, err)
+
+	msg := &model.Message{ID: "1", Recipient: "+989121234567", Payload: "your code is 1234"}
+
+	sealed, err := cipher.seal(msg)
+	require.NoError(t, err)
+	require.True(t, sealed.Encrypted)
+	require.NotEqual(t, msg.Recipient, sealed.Recipient)
+	require.NotEqual(t, msg.Payload, sealed.Payload)
+	require.Equal(t, "+989121234567", msg.Recipient, "the message should not be modified")
+
+	recipientHMAC, err := cipher.recipientHMAC(msg.Recipient)
+	require.NoError(t, err)
+	require.Equal(t, recipientHMAC, sealed.RecipientHMAC)
+
+	plaintext := model.Message{ID: "2", Recipient: "+905324567891", Payload: "stored before encryption"}
+
+	msgs := []model.Message{*sealed, plaintext}
+	require.NoError(t, cipher.open(msgs))
+	require.Equal(t, msg.Recipient, msgs[0].Recipient)
+	require.Equal(t, msg.Payload, msgs[0].Payload)
+	require.Equal(t, plaintext, msgs[1])
+
+	other, err := NewMessageCipher(config.Encryption{Enabled: true, Keys: keyring("other"), HMACKeys: keyring("other")})
+	require.NoError(t, err)
+	require.Error(t, other.open([]model.Message{*sealed}))
+}
+
+func TestMessageCipherKeys(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name     string
+		cfg      config.Encryption
+		expected error
+	}{
+		{
+			name:     "no keys",
+			cfg:      config.Encryption{Enabled: true},
+			expected: security.ErrInvalidKeyring,
+		},
+		{
+			name:     "no hmac keys",
+			cfg:      config.Encryption{Enabled: true, Keys: keyring("key")},
+			expected: security.ErrInvalidKeyring,
+		},
+		{
+			name:     "empty key",
+			cfg:      config.Encryption{Enabled: true, Keys: keyring(""), HMACKeys: keyring("mac")},
+			expected: security.ErrInsecureKey,
+		},
+		{
+			name:     "default key",
+			cfg:      config.Encryption{Enabled: true, Keys: keyring("key"), HMACKeys: keyring("secret")},
+			expected: security.ErrInsecureKey,
+		},
+		{
+			name: "default key among rotated keys",
+			cfg: config.Encryption{
+				Enabled:  true,
+				Keys:     config.Keyring{Active: "2", Keys: map[string]string{"1": "secret", "2": "key"}},
+				HMACKeys: keyring("mac"),
+			},
+			expected: security.ErrInsecureKey,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := NewMessageCipher(tc.cfg)
+			require.ErrorIs(t, err, tc.expected)
+		})
+	}
+}
+
+func TestMessageCipherDisabled(t *testing.T) {
+	t.Parallel()
+
+	for _, cfg := range []config.Encryption{
+		{Keys: keyring("key"), HMACKeys: keyring("mac")},
+		{},
+	} {
+		cipher, err := NewMessageCipher(cfg)
+		require.NoError(t, err)
+
+		msg := &model.Message{ID: "1", Recipient: "+989121234567", Payload: "your code is 1234"}
+
+		sealed, err := cipher.seal(msg)
+		require.NoError(t, err)
+		require.Equal(t, msg, sealed)
+
+		recipientHMAC, err := cipher.recipientHMAC(msg.Recipient)
+		require.NoError(t, err)
+		require.Empty(t, recipientHMAC)
+	}
+
+	enabled, err := NewMessageCipher(config.Encryption{Enabled: true, Keys: keyring("key"), HMACKeys: keyring("mac")})
+	require.NoError(t, err)
+
+	disabled, err := NewMessageCipher(config.Encryption{Keys: keyring("key"), HMACKeys: keyring("mac")})
+	require.NoError(t, err)
+
+	expected, err := enabled.recipientHMACs("+989121234567")
+	require.NoError(t, err)
+
+	recipientHMACs, err := disabled.recipientHMACs("+989121234567")
+	require.NoError(t, err)
+	require.Equal(t, expected, recipientHMACs, "messages encrypted before encryption was disabled should be looked up")
+
+	cipher, err := NewMessageCipher(config.Encryption{})
+	require.NoError(t, err)
+
+	recipientHMACs, err = cipher.recipientHMACs("+989121234567")
+	require.NoError(t, err)
+	require.Empty(t, recipientHMACs)
+
+	require.ErrorIs(t, cipher.open([]model.Message{{ID: "1", Encrypted: true}}), ErrMissingKeys,
+		"messages encrypted before encryption was disabled can't be read without keys")
+}
+
+func TestMessageCipherRotation(t *testing.T) {
+	t.Parallel()
+
+	old, err := NewMessageCipher(config.Encryption{Enabled: true, Keys: keyring("old"), HMACKeys: keyring("old")})
+	require.NoError(t, err)
+
+	msg := &model.Message{ID: "1", Recipient: "+989121234567", Payload: "your code is 1234"}
+
+	sealed, err := old.seal(msg)
+	require.NoError(t, err)
+
+	rotated := config.Keyring{Active: "2", Keys: map[string]string{"1": "old", "2": "new"}}
+
+	cipher, err := NewMessageCipher(config.Encryption{Enabled: true, Keys: rotated, HMACKeys: rotated})
+	require.NoError(t, err)
+
+	msgs := []model.Message{*sealed}
+	require.NoError(t, cipher.open(msgs))
+	require.Equal(t, msg.Payload, msgs[0].Payload)
+
+	recipientHMACs, err := cipher.recipientHMACs(msg.Recipient)
+	require.NoError(t, err)
+	require.Contains(t, recipientHMACs, sealed.RecipientHMAC)
+
+	resealed, err := cipher.seal(msg)
+	require.NoError(t, err)
+	require.NotEqual(t, sealed.RecipientHMAC, resealed.RecipientHMAC)
+	require.Contains(t, recipientHMACs, resealed.RecipientHMAC)
+}
@@ -0,0 +1,98 @@
+package repository
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/jinzhu/gorm"
+)
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// MessageFilter narrows down and paginates the messages of a user.
+// Zero values do not filter, messages are ordered from the newest unless Ascending is set.
+type MessageFilter struct {
+	Status    string
+	Recipient string
+	Language  string
+	From      time.Time
+	To        time.Time
+	Ascending bool
+	// After is the position of the last message of the previous page.
+	After *MessageCursor
+	Limit int
+	// recipientHMACs match the recipient of encrypted messages.
+	recipientHMACs []string
+}
+
+// MessageCursor is the position of a message in the (created_at, id) order.
+type MessageCursor struct {
+	CreatedAt time.Time `json:"created_at"`
+	ID        string    `json:"id"`
+}
+
+// String encodes the cursor to be passed to clients as an opaque value.
+func (c MessageCursor) String() string {
+	raw, _ := json.Marshal(c)
+
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+func ParseMessageCursor(s string) (*MessageCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	var cursor MessageCursor
+	if err := json.Unmarshal(raw, &cursor); err != nil || cursor.ID == "" {
+		return nil, ErrInvalidCursor
+	}
+
+	return &cursor, nil
+}
+
+func (f MessageFilter) apply(query *gorm.DB) *gorm.DB {
+	if f.Status != "" {
+		query = query.Where("status = ?", f.Status)
+	}
+
+	if f.Recipient != "" {
+		if len(f.recipientHMACs) > 0 {
+			query = query.Where("(recipient = ? or recipient_hmac in (?))", f.Recipient, f.recipientHMACs)
+		} else {
+			query = query.Where("recipient = ?", f.Recipient)
+		}
+	}
+
+	if f.Language != "" {
+		query = query.Where("language = ?", f.Language)
+	}
+
+	if !f.From.IsZero() {
+		query = query.Where("created_at >= ?", f.From)
+	}
+
+	if !f.To.IsZero() {
+		query = query.Where("created_at < ?", f.To)
+	}
+
+	order, direction := "desc", "<"
+	if f.Ascending {
+		order, direction = "asc", ">"
+	}
+
+	if f.After != nil {
+		query = query.Where("(created_at, id) "+direction+" (?, ?)", f.After.CreatedAt, f.After.ID)
+	}
+
+	query = query.Order("created_at " + order).Order("id " + order)
+
+	if f.Limit > 0 {
+		query = query.Limit(f.Limit)
+	}
+
+	return query
+}
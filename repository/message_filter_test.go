@@ -0,0 +1,27 @@
+package repository
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMessageCursor(t *testing.T) {
+	t.Parallel()
+
+	cursor := MessageCursor{
+		CreatedAt: time.Date(2026, 10, 18, 10, 0, 0, 123000, time.UTC),
+		ID:        "2b1c4fd5-7a3e-4c8e-9f5a-0e7c1d2b3a4f",
+	}
+
+	parsed, err := ParseMessageCursor(cursor.String())
+	require.NoError(t, err)
+	require.True(t, cursor.CreatedAt.Equal(parsed.CreatedAt))
+	require.Equal(t, cursor.ID, parsed.ID)
+
+	for _, invalid := range []string{"not base64!", "bm90IGpzb24", "e30"} {
+		_, err := ParseMessageCursor(invalid)
+		require.ErrorIs(t, err, ErrInvalidCursor, invalid)
+	}
+}
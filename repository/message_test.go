@@ -4,7 +4,9 @@ import (
 	"arvanch/config"
 	"arvanch/db"
 	"arvanch/model"
+	"context"
 	"testing"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/jinzhu/gorm"
@@ -18,9 +20,14 @@ type MessageRepoSuiteTest struct {
 }
 
 func (suite *MessageRepoSuiteTest) SetupSuite() {
-	database := db.WithRetry(db.Create, config.Init().Postgres)
+	database, err := db.WithRetry(context.Background(), db.Create, config.Init().Postgres)
+	suite.Require().NoError(err)
+
+	cipher, err := NewMessageCipher(config.Init().Encryption)
+	suite.Require().NoError(err)
+
 	suite.db = database
-	suite.repo = NewMessageRepo(database)
+	suite.repo = NewMessageRepo(db.NewResolver(database, nil, 0), cipher)
 }
 
 // nolint:funlen,gocognit
@@ -40,10 +47,19 @@ func (suite *MessageRepoSuiteTest) TestInsertMessage() {
 		{
 			name: "successful send en",
 			msg: &model.Message{
-				ID:       uuid.New().String(),
-				UserID:   userID,
-				Payload:  "payload 1",
-				Language: "en",
+				ID:              uuid.New().String(),
+				UserID:          userID,
+				Recipient:       "+989121234567",
+				SenderID:        "arvan",
+				Payload:         "payload 1",
+				Language:        "en",
+				Status:          model.MessageStatusAccepted,
+				Segments:        1,
+				Price:           100,
+				Provider:        "rahyab",
+				ClientReference: "order-1",
+				Metadata:        model.Metadata{"campaign": "welcome"},
+				RequestID:       "req-1",
 			},
 		},
 		{
@@ -88,7 +104,7 @@ func (suite *MessageRepoSuiteTest) TestInsertMessage() {
 		tc := tcs[i]
 
 		suite.Run(tc.name, func() {
-			err := suite.repo.InsertMessage(tc.msg)
+			err := suite.repo.InsertMessage(context.Background(), tc.msg)
 
 			if tc.errExpected {
 				suite.Error(err)
@@ -100,14 +116,187 @@ func (suite *MessageRepoSuiteTest) TestInsertMessage() {
 
 				suite.Equal(m.ID, tc.msg.ID)
 				suite.Equal(m.UserID, tc.msg.UserID)
+				suite.Equal(m.Recipient, tc.msg.Recipient)
 				suite.Equal(m.Language, tc.msg.Language)
 				suite.Equal(m.Payload, tc.msg.Payload)
+				suite.Equal(m.SenderID, tc.msg.SenderID)
+				suite.Equal(m.Segments, tc.msg.Segments)
+				suite.Equal(m.Price, tc.msg.Price)
+				suite.Equal(m.Provider, tc.msg.Provider)
+				suite.Equal(m.ClientReference, tc.msg.ClientReference)
+				suite.Equal(len(m.Metadata), len(tc.msg.Metadata))
+				suite.Equal(m.RequestID, tc.msg.RequestID)
+				suite.False(m.CreatedAt.IsZero())
 			}
 
 		})
 	}
 }
 
+func (suite *MessageRepoSuiteTest) TestGetUserMessages() {
+	userID := uuid.New().String()
+	accID := uuid.New().String()
+
+	suite.NoError(suite.db.Create(&model.Account{ID: accID}).Error)
+
+	suite.NoError(suite.db.Create(&model.User{AccountID: accID, Name: "user_test", ID: userID}).Error)
+
+	createdAt := time.Now().Truncate(time.Second)
+
+	ids := make([]string, 5)
+
+	for i := range ids {
+		ids[i] = uuid.New().String()
+
+		language := "en"
+		if i%2 == 1 {
+			language = "fa"
+		}
+
+		suite.NoError(suite.repo.InsertMessage(context.Background(), &model.Message{
+			ID:        ids[i],
+			UserID:    userID,
+			Recipient: "+989121234567",
+			Payload:   "payload",
+			Language:  language,
+			Status:    model.MessageStatusAccepted,
+			CreatedAt: createdAt.Add(time.Duration(i) * time.Second),
+		}))
+	}
+
+	messageIDs := func(filter MessageFilter) []string {
+		msgs, err := suite.repo.GetUserMessages(context.Background(), userID, filter)
+		suite.Require().NoError(err)
+
+		result := make([]string, len(msgs))
+		for i := range msgs {
+			result[i] = msgs[i].ID
+		}
+
+		return result
+	}
+
+	suite.Equal([]string{ids[4], ids[3]}, messageIDs(MessageFilter{Limit: 2}))
+
+	cursor := &MessageCursor{CreatedAt: createdAt.Add(3 * time.Second), ID: ids[3]}
+	suite.Equal([]string{ids[2], ids[1]}, messageIDs(MessageFilter{Limit: 2, After: cursor}))
+	suite.Equal([]string{ids[4]}, messageIDs(MessageFilter{Ascending: true, After: cursor}))
+
+	suite.Equal([]string{ids[3], ids[1]}, messageIDs(MessageFilter{Language: "fa"}))
+	suite.Equal([]string{ids[1], ids[2]}, messageIDs(MessageFilter{
+		Ascending: true,
+		From:      createdAt.Add(time.Second),
+		To:        createdAt.Add(3 * time.Second),
+	}))
+	suite.Empty(messageIDs(MessageFilter{Recipient: "+989120000000"}))
+}
+
+func (suite *MessageRepoSuiteTest) TestUpdateMessageStatus() {
+	userID := uuid.New().String()
+	suite.NoError(suite.repo.InsertUserWithAccount(context.Background(), userID, "user_test"))
+
+	msgID := uuid.New().String()
+
+	suite.NoError(suite.repo.InsertMessage(context.Background(), &model.Message{
+		ID:        msgID,
+		UserID:    userID,
+		Recipient: "+989121234567",
+		Payload:   "payload",
+		Language:  "en",
+		Status:    model.MessageStatusAccepted,
+	}))
+
+	msg, updated, err := suite.repo.UpdateMessageStatus(context.Background(), msgID, model.MessageStatusDelivered)
+	suite.NoError(err)
+	suite.True(updated)
+	suite.Equal(model.MessageStatusDelivered, msg.Status)
+	suite.Equal("+989121234567", msg.Recipient)
+
+	msg, updated, err = suite.repo.UpdateMessageStatus(context.Background(), msgID, model.MessageStatusFailed)
+	suite.NoError(err)
+	suite.False(updated)
+	suite.Equal(model.MessageStatusDelivered, msg.Status)
+
+	_, _, err = suite.repo.UpdateMessageStatus(context.Background(), uuid.New().String(), model.MessageStatusFailed)
+	suite.ErrorIs(err, model.ErrRecordNotFound)
+}
+
+func (suite *MessageRepoSuiteTest) TestGetUserProfile() {
+	userID := uuid.New().String()
+	suite.NoError(suite.repo.InsertUserWithAccount(context.Background(), userID, "user_test"))
+
+	profile, err := suite.repo.GetUserProfile(context.Background(), userID)
+	suite.NoError(err)
+	suite.Equal(userID, profile.User.ID)
+	suite.NotEmpty(profile.AccountID)
+
+	_, err = suite.repo.GetUserProfile(context.Background(), uuid.New().String())
+	suite.ErrorIs(err, model.ErrRecordNotFound)
+}
+
+func (suite *MessageRepoSuiteTest) TestInsertChargedMessage() {
+	userID := uuid.New().String()
+	suite.NoError(suite.repo.InsertUserWithAccount(context.Background(), userID, "user_test"))
+
+	profile, err := suite.repo.GetUserProfile(context.Background(), userID)
+	suite.NoError(err)
+
+	msg := &model.Message{
+		ID:        uuid.New().String(),
+		UserID:    userID,
+		Recipient: "+989121234567",
+		Payload:   "payload",
+		Language:  "en",
+		Status:    model.MessageStatusAccepted,
+		Price:     100,
+	}
+
+	err = suite.repo.InsertChargedMessage(context.Background(), profile.AccountID, msg)
+	suite.ErrorIs(err, model.ErrInsufficientBalance)
+
+	var count int
+	suite.NoError(suite.db.Model(&model.Message{}).Where("id = ?", msg.ID).Count(&count).Error)
+	suite.Zero(count, "no message should be stored when the debit fails")
+
+	suite.NoError(suite.repo.IncrementAccountBalance(context.Background(), profile.AccountID, 100))
+	suite.NoError(suite.repo.InsertChargedMessage(context.Background(), profile.AccountID, msg))
+
+	profile, err = suite.repo.GetUserProfile(context.Background(), userID)
+	suite.NoError(err)
+	suite.Zero(profile.Balance)
+}
+
+func (suite *MessageRepoSuiteTest) TestWhitelistedNumbers() {
+	accID := uuid.New().String()
+	otherAccID := uuid.New().String()
+
+	suite.NoError(suite.db.Create(&model.Account{ID: accID}).Error)
+	suite.NoError(suite.db.Create(&model.Account{ID: otherAccID}).Error)
+
+	suite.NoError(suite.repo.SetWhitelistedNumbers(context.Background(), accID, []string{"+989121234567", "+905321234567"}))
+
+	recipients, err := suite.repo.GetWhitelistedNumbers(context.Background(), accID)
+	suite.NoError(err)
+	suite.Equal([]string{"+905321234567", "+989121234567"}, recipients)
+
+	whitelisted, err := suite.repo.IsWhitelisted(context.Background(), accID, "+989121234567")
+	suite.NoError(err)
+	suite.True(whitelisted)
+
+	whitelisted, err = suite.repo.IsWhitelisted(context.Background(), otherAccID, "+989121234567")
+	suite.NoError(err)
+	suite.False(whitelisted, "white lists should be per account")
+
+	suite.NoError(suite.repo.SetWhitelistedNumbers(context.Background(), accID, []string{"+989127654321"}))
+
+	recipients, err = suite.repo.GetWhitelistedNumbers(context.Background(), accID)
+	suite.NoError(err)
+	suite.Equal([]string{"+989127654321"}, recipients, "setting should replace the white list")
+
+	err = suite.repo.SetWhitelistedNumbers(context.Background(), uuid.New().String(), []string{"+989121234567"})
+	suite.ErrorIs(err, model.ErrRecordNotFound)
+}
+
 func TestSMS(t *testing.T) {
 	suite.Run(t, new(MessageRepoSuiteTest))
 }
@@ -1,70 +1,190 @@
 package repository
 
 import (
-	"arvanch/model"
+	"context"
 	"fmt"
 
+	"arvanch/db"
+	"arvanch/log"
+	"arvanch/model"
+
 	"github.com/google/uuid"
 	"github.com/jinzhu/gorm"
 )
 
 type MessageRepository interface {
-	InsertMessage(*model.Message) error
+	InsertMessage(ctx context.Context, msg *model.Message) error
+
+	// InsertChargedMessage debits the message's price from the account and inserts the message in one transaction,
+	// so no message is stored when the account has insufficient balance.
+	InsertChargedMessage(ctx context.Context, accountID string, msg *model.Message) error
+
+	InsertUserWithAccount(ctx context.Context, userID, name string) error
+
+	GetUserMessages(ctx context.Context, userID string, filter MessageFilter) ([]model.Message, error)
 
-	InsertUserWithAccount(userID, name string) error
+	GetUserProfile(ctx context.Context, userID string) (model.Profile, error)
 
-	GetUserMessages(userID string) ([]model.Message, error)
+	IncrementAccountBalance(ctx context.Context, accountID string, amount int64) error
 
-	GetUserProfile(userID string) (model.Profile, error)
+	SetAccountSandbox(ctx context.Context, accountID string, sandbox bool) error
 
-	IncrementAccountBalance(accountID string, amount int64) error
+	// SetWhitelistedNumbers replaces the recipients the account can send to in sandbox mode.
+	SetWhitelistedNumbers(ctx context.Context, accountID string, recipients []string) error
+
+	GetWhitelistedNumbers(ctx context.Context, accountID string) ([]string, error)
+
+	IsWhitelisted(ctx context.Context, accountID, recipient string) (bool, error)
+
+	// UpdateMessageStatus sets the final status of a message and returns the message. It returns false
+	// when the message already had a final status, as providers may report a message more than once.
+	UpdateMessageStatus(ctx context.Context, id, status string) (model.Message, bool, error)
+
+	// EncryptBatch encrypts up to size messages stored in plaintext or with a rotated key
+	// and returns how many it encrypted.
+	EncryptBatch(ctx context.Context, size int) (int, error)
 }
 
+// MessageRepo reads users' messages and profiles from replicas when there are any.
+// Messages are encrypted and decrypted by the cipher.
 type MessageRepo struct {
-	db *gorm.DB
+	db       *gorm.DB
+	resolver *db.Resolver
+	cipher   *MessageCipher
 	MessageRepository
 }
 
-func NewMessageRepo(db *gorm.DB) MessageRepository {
-	return &MessageRepo{db: db}
+func NewMessageRepo(resolver *db.Resolver, cipher *MessageCipher) MessageRepository {
+	return &MessageRepo{db: resolver.Primary(), resolver: resolver, cipher: cipher}
 }
 
-func (m *MessageRepo) InsertMessage(msg *model.Message) error {
-	return m.db.Create(msg).Error
+func (m *MessageRepo) InsertMessage(ctx context.Context, msg *model.Message) (err error) {
+	span := startSpan(ctx, "MessageRepo.InsertMessage")
+	defer func() { endSpan(span, err) }()
+
+	sealed, err := m.cipher.seal(msg)
+	if err != nil {
+		return err
+	}
+
+	if err := m.db.Create(sealed).Error; err != nil {
+		return model.ParseError(err)
+	}
+
+	msg.CreatedAt = sealed.CreatedAt
+
+	m.resolver.Wrote(msg.UserID)
+
+	return nil
 }
 
-func (m *MessageRepo) GetUserMessages(userID string) ([]model.Message, error) {
+func (m *MessageRepo) InsertChargedMessage(ctx context.Context, accountID string, msg *model.Message) (err error) {
+	span := startSpan(ctx, "MessageRepo.InsertChargedMessage")
+	defer func() { endSpan(span, err) }()
+
+	sealed, err := m.cipher.seal(msg)
+	if err != nil {
+		return err
+	}
+
+	err = m.db.Transaction(func(tx *gorm.DB) error {
+		// debits below zero violate the balance check, which is parsed to model.ErrInsufficientBalance.
+		result := tx.Model(&model.Account{}).
+			Where("id = ?", accountID).
+			Update("balance", gorm.Expr("balance - ?", msg.Price))
+
+		if result.Error != nil {
+			return result.Error
+		}
+
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+
+		return tx.Create(sealed).Error
+	})
+
+	if err != nil {
+		return model.ParseError(err)
+	}
+
+	msg.CreatedAt = sealed.CreatedAt
+
+	m.resolver.Wrote(msg.UserID)
+	m.wroteAccount(ctx, accountID)
+
+	return nil
+}
+
+// GetUserMessages returns a page of the user's messages ordered by creation time.
+func (m *MessageRepo) GetUserMessages(ctx context.Context, userID string,
+	filter MessageFilter) (_ []model.Message, err error) {
+	span := startSpan(ctx, "MessageRepo.GetUserMessages")
+	defer func() { endSpan(span, err) }()
+
+	if filter.recipientHMACs, err = m.cipher.recipientHMACs(filter.Recipient); err != nil {
+		return nil, err
+	}
+
 	var messages []model.Message
 
-	err := m.db.
-		Where("user_id = ?", userID).
-		Find(&messages).Error
+	err = m.resolver.Read(ctx, userID, func(conn *gorm.DB) error {
+		messages = nil
+
+		return filter.apply(conn.Where("user_id = ?", userID)).
+			Find(&messages).Error
+	})
 
 	if err != nil {
+		return nil, model.ParseError(err)
+	}
+
+	if err := m.cipher.open(messages); err != nil {
 		return nil, err
 	}
 
 	return messages, nil
 }
 
-func (m *MessageRepo) GetUserProfile(userID string) (model.Profile, error) {
+func (m *MessageRepo) GetUserProfile(ctx context.Context, userID string) (_ model.Profile, err error) {
+	span := startSpan(ctx, "MessageRepo.GetUserProfile")
+	defer func() { endSpan(span, err) }()
+
 	var profile model.Profile
 
-	err := m.db.Table("users").
-		Select("users.id, users.name, users.account_id, accounts.id as account_id, accounts.balance").
-		Joins("left join accounts on accounts.id = users.account_id").
-		Where("users.id = ?", userID).
-		Scan(&profile).Error
+	err = m.resolver.Read(ctx, userID, func(conn *gorm.DB) error {
+		profile = model.Profile{}
+
+		err := conn.Table("users").
+			Select("users.id, users.name, users.account_id, accounts.id as account_id, accounts.balance, accounts.sandbox").
+			Joins("left join accounts on accounts.id = users.account_id").
+			Where("users.id = ?", userID).
+			Scan(&profile).Error
+		if err != nil {
+			return err
+		}
+
+		// users created by another process may not be on the replica yet, they are looked up on the primary.
+		if profile.User.ID == "" {
+			return gorm.ErrRecordNotFound
+		}
+
+		return nil
+	})
 
+	// an empty profile is never returned, as callers would go on with a user that does not exist.
 	if err != nil {
-		return profile, err
+		return model.Profile{}, model.ParseError(err)
 	}
 
 	return profile, nil
 }
 
-func (m *MessageRepo) InsertUserWithAccount(userID, name string) error {
-	return m.db.Transaction(func(tx *gorm.DB) error {
+func (m *MessageRepo) InsertUserWithAccount(ctx context.Context, userID, name string) (err error) {
+	span := startSpan(ctx, "MessageRepo.InsertUserWithAccount")
+	defer func() { endSpan(span, err) }()
+
+	err = m.db.Transaction(func(tx *gorm.DB) error {
 		// Create account
 		account := model.Account{
 			ID: uuid.New().String(), // or any ID generator
@@ -85,20 +205,266 @@ func (m *MessageRepo) InsertUserWithAccount(userID, name string) error {
 
 		return nil
 	})
+
+	if err != nil {
+		return model.ParseError(err)
+	}
+
+	m.resolver.Wrote(userID)
+
+	return nil
 }
 
-func (m *MessageRepo) IncrementAccountBalance(accountID string, amount int64) error {
+func (m *MessageRepo) IncrementAccountBalance(ctx context.Context, accountID string, amount int64) (err error) {
+	span := startSpan(ctx, "MessageRepo.IncrementAccountBalance")
+	defer func() { endSpan(span, err) }()
+
 	result := m.db.Model(&model.Account{}).
 		Where("id = ?", accountID).
 		Update("balance", gorm.Expr("balance + ?", amount))
 
+	// debits below zero violate the balance check, which is parsed to model.ErrInsufficientBalance.
 	if result.Error != nil {
-		return result.Error
+		return model.ParseError(result.Error)
 	}
 
 	if result.RowsAffected == 0 {
-		return fmt.Errorf("insufficient balance")
+		return model.ErrRecordNotFound
 	}
 
+	m.wroteAccount(ctx, accountID)
+
 	return nil
 }
+
+func (m *MessageRepo) SetAccountSandbox(ctx context.Context, accountID string, sandbox bool) (err error) {
+	span := startSpan(ctx, "MessageRepo.SetAccountSandbox")
+	defer func() { endSpan(span, err) }()
+
+	result := m.db.Model(&model.Account{}).
+		Where("id = ?", accountID).
+		Update("sandbox", sandbox)
+
+	if result.Error != nil {
+		return model.ParseError(result.Error)
+	}
+
+	if result.RowsAffected == 0 {
+		return model.ErrRecordNotFound
+	}
+
+	m.wroteAccount(ctx, accountID)
+
+	return nil
+}
+
+func (m *MessageRepo) SetWhitelistedNumbers(ctx context.Context, accountID string, recipients []string) (err error) {
+	span := startSpan(ctx, "MessageRepo.SetWhitelistedNumbers")
+	defer func() { endSpan(span, err) }()
+
+	err = m.db.Transaction(func(tx *gorm.DB) error {
+		// lock the account, so concurrent replacements of its list don't interleave.
+		var account model.Account
+		if err := tx.Set("gorm:query_option", "FOR UPDATE").Where("id = ?", accountID).First(&account).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Where("account_id = ?", accountID).Delete(&model.WhitelistedNumber{}).Error; err != nil {
+			return err
+		}
+
+		for _, recipient := range recipients {
+			if err := tx.Create(&model.WhitelistedNumber{AccountID: accountID, Recipient: recipient}).Error; err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return model.ParseError(err)
+	}
+
+	m.wroteAccount(ctx, accountID)
+
+	return nil
+}
+
+func (m *MessageRepo) GetWhitelistedNumbers(ctx context.Context, accountID string) (_ []string, err error) {
+	span := startSpan(ctx, "MessageRepo.GetWhitelistedNumbers")
+	defer func() { endSpan(span, err) }()
+
+	var recipients []string
+
+	err = m.resolver.Read(ctx, accountID, func(conn *gorm.DB) error {
+		recipients = nil
+
+		return conn.Model(&model.WhitelistedNumber{}).
+			Where("account_id = ?", accountID).
+			Order("recipient").
+			Pluck("recipient", &recipients).Error
+	})
+
+	if err != nil {
+		return nil, model.ParseError(err)
+	}
+
+	return recipients, nil
+}
+
+func (m *MessageRepo) IsWhitelisted(ctx context.Context, accountID, recipient string) (_ bool, err error) {
+	span := startSpan(ctx, "MessageRepo.IsWhitelisted")
+	defer func() { endSpan(span, err) }()
+
+	var count int
+
+	err = m.resolver.Read(ctx, accountID, func(conn *gorm.DB) error {
+		err := conn.Model(&model.WhitelistedNumber{}).
+			Where("account_id = ? and recipient = ?", accountID, recipient).
+			Count(&count).Error
+		if err != nil {
+			return err
+		}
+
+		// numbers white listed by the accounting process may not be on the replica yet.
+		if count == 0 {
+			return gorm.ErrRecordNotFound
+		}
+
+		return nil
+	})
+
+	if gorm.IsRecordNotFoundError(err) {
+		return false, nil
+	}
+
+	if err != nil {
+		return false, model.ParseError(err)
+	}
+
+	return count > 0, nil
+}
+
+// wroteAccount marks the account and its users as written. Profiles are read by user,
+// so reads of the users' profiles go to the primary until replicas have the account's change.
+func (m *MessageRepo) wroteAccount(ctx context.Context, accountID string) {
+	m.resolver.Wrote(accountID)
+
+	var userIDs []string
+
+	if err := m.db.Model(&model.User{}).Where("account_id = ?", accountID).Pluck("id", &userIDs).Error; err != nil {
+		log.FromContext(ctx).Warnf("marking users of account %s as written failed: %s", accountID, err)
+
+		return
+	}
+
+	for _, userID := range userIDs {
+		m.resolver.Wrote(userID)
+	}
+}
+
+func (m *MessageRepo) UpdateMessageStatus(ctx context.Context, id, status string) (_ model.Message, _ bool, err error) {
+	span := startSpan(ctx, "MessageRepo.UpdateMessageStatus")
+	defer func() { endSpan(span, err) }()
+
+	var (
+		msg     model.Message
+		updated bool
+	)
+
+	err = m.db.Transaction(func(tx *gorm.DB) error {
+		// lock the message, so concurrent reports of a message are recorded once.
+		if err := tx.Set("gorm:query_option", "FOR UPDATE").Where("id = ?", id).First(&msg).Error; err != nil {
+			return err
+		}
+
+		if model.IsFinalStatus(msg.Status) {
+			log.FromContext(ctx).Debugf("message %s already has final status %s, %s is ignored", id, msg.Status, status)
+
+			return nil
+		}
+
+		if err := tx.Model(&model.Message{}).Where("id = ?", id).Update("status", status).Error; err != nil {
+			return err
+		}
+
+		msg.Status, updated = status, true
+
+		return nil
+	})
+
+	if err != nil {
+		return msg, false, model.ParseError(err)
+	}
+
+	if updated {
+		m.resolver.Wrote(msg.UserID)
+	}
+
+	msgs := []model.Message{msg}
+	if err := m.cipher.open(msgs); err != nil {
+		return msg, false, err
+	}
+
+	return msgs[0], updated, nil
+}
+
+func (m *MessageRepo) EncryptBatch(ctx context.Context, size int) (n int, err error) {
+	span := startSpan(ctx, "MessageRepo.EncryptBatch")
+	defer func() { endSpan(span, err) }()
+
+	if !m.cipher.enabled {
+		return 0, ErrEncryptionDisabled
+	}
+
+	active := escapeLike(m.cipher.aes.ActivePrefix()) + "%"
+
+	err = m.db.Transaction(func(tx *gorm.DB) error {
+		var messages []model.Message
+
+		// skip rows locked by another run, so the command can run in parallel.
+		err := tx.Raw(
+			"SELECT id, recipient, payload, encrypted FROM messages "+
+				"WHERE NOT encrypted OR payload NOT LIKE ? OR recipient NOT LIKE ? "+
+				"ORDER BY id LIMIT ? FOR UPDATE SKIP LOCKED",
+			active, active, size,
+		).Scan(&messages).Error
+		if err != nil {
+			return err
+		}
+
+		if err := m.cipher.open(messages); err != nil {
+			return err
+		}
+
+		for i := range messages {
+			sealed, err := m.cipher.seal(&messages[i])
+			if err != nil {
+				return err
+			}
+
+			err = tx.Model(&model.Message{}).
+				Where("id = ?", sealed.ID).
+				Updates(map[string]interface{}{
+					"recipient":      sealed.Recipient,
+					"recipient_hmac": sealed.RecipientHMAC,
+					"payload":        sealed.Payload,
+					"encrypted":      true,
+				}).Error
+			if err != nil {
+				return fmt.Errorf("failed to encrypt message %s: %w", sealed.ID, err)
+			}
+		}
+
+		n = len(messages)
+
+		return nil
+	})
+
+	if err != nil {
+		return 0, err
+	}
+
+	return n, nil
+}
@@ -0,0 +1,106 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"arvanch/db"
+	"arvanch/model"
+
+	"github.com/jinzhu/gorm"
+)
+
+// ErrPayloadSearchEncrypted is returned when searching payloads while messages are encrypted,
+// as the stored payloads are ciphertexts which would match nothing.
+var ErrPayloadSearchEncrypted = errors.New("payloads can not be searched while messages are encrypted")
+
+// MessageSearch is a support staff search over the message history of all users.
+// Payload matches messages containing it, case-insensitively, it can not be searched while messages are encrypted.
+type MessageSearch struct {
+	MessageFilter
+	UserID    string
+	AccountID string
+	Payload   string
+}
+
+type SearchRepository interface {
+	SearchMessages(ctx context.Context, search MessageSearch) ([]model.Message, error)
+
+	InsertSearchAudit(ctx context.Context, audit *model.SearchAudit) error
+}
+
+// SearchRepo runs searches on replicas when there are any.
+type SearchRepo struct {
+	db       *gorm.DB
+	resolver *db.Resolver
+	cipher   *MessageCipher
+}
+
+func NewSearchRepo(resolver *db.Resolver, cipher *MessageCipher) SearchRepository {
+	return &SearchRepo{db: resolver.Primary(), resolver: resolver, cipher: cipher}
+}
+
+func (s *SearchRepo) SearchMessages(ctx context.Context, search MessageSearch) (_ []model.Message, err error) {
+	span := startSpan(ctx, "SearchRepo.SearchMessages")
+	defer func() { endSpan(span, err) }()
+
+	if search.Payload != "" && s.cipher.enabled {
+		return nil, ErrPayloadSearchEncrypted
+	}
+
+	if search.recipientHMACs, err = s.cipher.recipientHMACs(search.Recipient); err != nil {
+		return nil, err
+	}
+
+	var messages []model.Message
+
+	err = s.resolver.Read(ctx, "", func(conn *gorm.DB) error {
+		messages = nil
+
+		return search.apply(conn).Find(&messages).Error
+	})
+
+	if err != nil {
+		return nil, model.ParseError(err)
+	}
+
+	if err := s.cipher.open(messages); err != nil {
+		return nil, err
+	}
+
+	return messages, nil
+}
+
+func (s *SearchRepo) InsertSearchAudit(ctx context.Context, audit *model.SearchAudit) (err error) {
+	span := startSpan(ctx, "SearchRepo.InsertSearchAudit")
+	defer func() { endSpan(span, err) }()
+
+	err = s.db.Create(audit).Error
+
+	return model.ParseError(err)
+}
+
+func (s MessageSearch) apply(query *gorm.DB) *gorm.DB {
+	if s.UserID != "" {
+		query = query.Where("user_id = ?", s.UserID)
+	}
+
+	if s.AccountID != "" {
+		query = query.Where("user_id in (select id from users where account_id = ?)", s.AccountID)
+	}
+
+	if s.Payload != "" {
+		query = query.Where("payload ilike ?", "%"+escapeLike(s.Payload)+"%")
+	}
+
+	return s.MessageFilter.apply(query)
+}
+
+// nolint:gochecknoglobals
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// escapeLike escapes the wildcards of a LIKE pattern, so the value is matched literally.
+func escapeLike(value string) string {
+	return likeEscaper.Replace(value)
+}
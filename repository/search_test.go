@@ -0,0 +1,30 @@
+package repository
+
+import (
+	"context"
+	"testing"
+
+	"arvanch/config"
+	"arvanch/db"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestEscapeLike(t *testing.T) {
+	t.Parallel()
+
+	require.Equal(t, "code", escapeLike("code"))
+	require.Equal(t, `100\% off\_now \\o/`, escapeLike(`100% off_now \o/`))
+}
+
+func TestSearchPayloadEncrypted(t *testing.T) {
+	t.Parallel()
+
+	cipher, err := NewMessageCipher(config.Encryption{Enabled: true, Keys: keyring("key"), HMACKeys: keyring("mac")})
+	require.NoError(t, err)
+
+	repo := NewSearchRepo(db.NewResolver(nil, nil, 0), cipher)
+
+	_, err = repo.SearchMessages(context.Background(), MessageSearch{Payload: "code"})
+	require.ErrorIs(t, err, ErrPayloadSearchEncrypted)
+}
@@ -0,0 +1,122 @@
+package repository
+
+import (
+	"context"
+
+	"arvanch/model"
+
+	"github.com/jinzhu/gorm"
+)
+
+// SuppressionRepository stores opt-out list entries.
+// An empty accountID refers to the global list. Entries are looked up by the HMACs of the recipient
+// with all keys, as entries added before a key rotation keep their old HMAC.
+type SuppressionRepository interface {
+	// InsertSuppression adds the entry unless the recipient is already on the list under any of recipientHMACs.
+	InsertSuppression(ctx context.Context, sup *model.Suppression, recipientHMACs []string) error
+
+	DeleteSuppression(ctx context.Context, accountID string, recipientHMACs []string) (bool, error)
+
+	GetSuppressions(ctx context.Context, accountID string) ([]model.Suppression, error)
+
+	IsSuppressed(ctx context.Context, accountID string, recipientHMACs []string) (bool, error)
+}
+
+type SuppressionRepo struct {
+	db *gorm.DB
+}
+
+func NewSuppressionRepo(db *gorm.DB) SuppressionRepository {
+	return &SuppressionRepo{db: db}
+}
+
+// InsertSuppression adds an entry to the list, adding an already suppressed recipient is a no-op.
+// The recipient is looked up by all its HMACs, so entries added before a key rotation are not duplicated.
+func (s *SuppressionRepo) InsertSuppression(ctx context.Context, sup *model.Suppression,
+	recipientHMACs []string) (err error) {
+	span := startSpan(ctx, "SuppressionRepo.InsertSuppression")
+	defer func() { endSpan(span, err) }()
+
+	scope, args := "account_id is null", []interface{}{sup.ID, sup.AccountID, sup.RecipientHMAC, sup.Source}
+
+	if sup.AccountID != nil {
+		scope = "account_id = ?"
+		args = append(args, *sup.AccountID)
+	}
+
+	err = s.db.Exec(
+		"INSERT INTO suppressions (id, account_id, recipient_hmac, source) SELECT ?, ?, ?, ? "+
+			"WHERE NOT EXISTS (SELECT 1 FROM suppressions WHERE "+scope+" AND recipient_hmac IN (?)) "+
+			"ON CONFLICT DO NOTHING",
+		append(args, recipientHMACs)...,
+	).Error
+
+	return model.ParseError(err)
+}
+
+// DeleteSuppression removes an entry from the list and reports whether it existed.
+func (s *SuppressionRepo) DeleteSuppression(ctx context.Context, accountID string,
+	recipientHMACs []string) (_ bool, err error) {
+	span := startSpan(ctx, "SuppressionRepo.DeleteSuppression")
+	defer func() { endSpan(span, err) }()
+
+	result := scopeAccount(s.db, accountID).
+		Where("recipient_hmac in (?)", recipientHMACs).
+		Delete(&model.Suppression{})
+
+	if result.Error != nil {
+		return false, model.ParseError(result.Error)
+	}
+
+	return result.RowsAffected > 0, nil
+}
+
+func (s *SuppressionRepo) GetSuppressions(ctx context.Context, accountID string) (_ []model.Suppression, err error) {
+	span := startSpan(ctx, "SuppressionRepo.GetSuppressions")
+	defer func() { endSpan(span, err) }()
+
+	var suppressions []model.Suppression
+
+	err = scopeAccount(s.db, accountID).
+		Order("created_at desc").
+		Find(&suppressions).Error
+
+	if err != nil {
+		return nil, model.ParseError(err)
+	}
+
+	return suppressions, nil
+}
+
+// IsSuppressed checks both the global list and the list of the given account.
+func (s *SuppressionRepo) IsSuppressed(ctx context.Context, accountID string,
+	recipientHMACs []string) (_ bool, err error) {
+	span := startSpan(ctx, "SuppressionRepo.IsSuppressed")
+	defer func() { endSpan(span, err) }()
+
+	var count int
+
+	query := s.db.Model(&model.Suppression{}).Where("recipient_hmac in (?)", recipientHMACs)
+
+	if accountID == "" {
+		query = query.Where("account_id is null")
+	} else {
+		query = query.Where("account_id is null or account_id = ?", accountID)
+	}
+
+	err = query.Count(&count).Error
+
+	if err != nil {
+		return false, model.ParseError(err)
+	}
+
+	return count > 0, nil
+}
+
+func scopeAccount(db *gorm.DB, accountID string) *gorm.DB {
+	if accountID == "" {
+		return db.Where("account_id is null")
+	}
+
+	return db.Where("account_id = ?", accountID)
+}
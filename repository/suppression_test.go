@@ -0,0 +1,71 @@
+package repository
+
+import (
+	"arvanch/config"
+	"arvanch/db"
+	"arvanch/model"
+	"arvanch/pkg/security"
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/jinzhu/gorm"
+	"github.com/stretchr/testify/suite"
+)
+
+type SuppressionRepoSuiteTest struct {
+	suite.Suite
+	repo SuppressionRepository
+	db   *gorm.DB
+}
+
+func (suite *SuppressionRepoSuiteTest) SetupSuite() {
+	database, err := db.WithRetry(context.Background(), db.Create, config.Init().Postgres)
+	suite.Require().NoError(err)
+
+	suite.db = database
+	suite.repo = NewSuppressionRepo(database)
+}
+
+func (suite *SuppressionRepoSuiteTest) TestInsertSuppressionAfterRotation() {
+	accID := uuid.New().String()
+	suite.NoError(suite.db.Create(&model.Account{ID: accID}).Error)
+
+	old, err := security.NewHMACKeyring(config.Keyring{Active: "1", Keys: map[string]string{"1": "old"}})
+	suite.Require().NoError(err)
+
+	rotated, err := security.NewHMACKeyring(config.Keyring{
+		Active: "2",
+		Keys:   map[string]string{"1": "old", "2": "new"},
+	})
+	suite.Require().NoError(err)
+
+	recipient := "+98912" + uuid.New().String()[:7]
+
+	insert := func(hmac *security.HMACKeyring) {
+		recipientHMAC, err := hmac.Transform(recipient)
+		suite.Require().NoError(err)
+
+		recipientHMACs, err := hmac.Candidates(recipient)
+		suite.Require().NoError(err)
+
+		suite.NoError(suite.repo.InsertSuppression(context.Background(), &model.Suppression{
+			ID:            uuid.New().String(),
+			AccountID:     &accID,
+			RecipientHMAC: recipientHMAC,
+			Source:        model.SuppressionSourceAPI,
+		}, recipientHMACs))
+	}
+
+	insert(old)
+	insert(rotated)
+	insert(rotated)
+
+	suppressions, err := suite.repo.GetSuppressions(context.Background(), accID)
+	suite.NoError(err)
+	suite.Len(suppressions, 1, "the recipient should not be added again under the rotated key")
+}
+
+func TestSuppression(t *testing.T) {
+	suite.Run(t, new(SuppressionRepoSuiteTest))
+}
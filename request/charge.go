@@ -27,3 +27,28 @@ func (r Account) Validate(reqValidator *validator.Validate) error {
 
 	return nil
 }
+
+type Sandbox struct {
+	Enabled *bool `json:"enabled"     validate:"required"`
+}
+
+func (r Sandbox) Validate(reqValidator *validator.Validate) error {
+	if err := reqValidator.Struct(r); err != nil {
+		return unwrapErrors(err)
+	}
+
+	return nil
+}
+
+// Whitelist replaces the recipients a sandbox account can send to.
+type Whitelist struct {
+	PhoneNumbers []string `json:"phone_numbers" validate:"max=100,dive,required,phone_number,max=100"`
+}
+
+func (r Whitelist) Validate(reqValidator *validator.Validate) error {
+	if err := reqValidator.Struct(r); err != nil {
+		return unwrapErrors(err)
+	}
+
+	return nil
+}
@@ -0,0 +1,24 @@
+package request
+
+import (
+	"time"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// DLR is a delivery report of a message, reported by the provider.
+// DoneAt is when the message reached the status, the time of the report is used when it is not set.
+type DLR struct {
+	MessageID string    `json:"message_id"  validate:"required,uuid"`
+	Status    string    `json:"status"      validate:"required,oneof=delivered failed expired"`
+	ErrorCode string    `json:"error_code"  validate:"max=100"`
+	DoneAt    time.Time `json:"done_at"`
+}
+
+func (r DLR) Validate(reqValidator *validator.Validate) error {
+	if err := reqValidator.Struct(r); err != nil {
+		return unwrapErrors(err)
+	}
+
+	return nil
+}
@@ -0,0 +1,42 @@
+package request
+
+import (
+	"time"
+
+	"github.com/go-playground/validator/v10"
+)
+
+const (
+	DefaultMessagesLimit = 20
+	MaxMessagesLimit     = 100
+)
+
+// Messages is the query of listing a user's messages.
+// Cursor is the next_cursor of the previous page, From and To are RFC3339 times.
+type Messages struct {
+	Cursor    string    `json:"cursor,omitempty"    query:"cursor"`
+	Limit     int       `json:"limit,omitempty"     query:"limit"     validate:"omitempty,min=1"`
+	Status    string    `json:"status,omitempty"    query:"status"    validate:"omitempty,oneof=accepted delivered failed expired"`
+	Recipient string    `json:"recipient,omitempty" query:"recipient" validate:"omitempty,phone_number,max=100"`
+	Locale    string    `json:"locale,omitempty"    query:"locale"    validate:"omitempty,locale"`
+	From      time.Time `json:"from"                query:"from"`
+	To        time.Time `json:"to"                  query:"to"`
+	Sort      string    `json:"sort,omitempty"      query:"sort"      validate:"omitempty,oneof=asc desc"`
+}
+
+func (r Messages) Validate(reqValidator *validator.Validate) error {
+	if err := reqValidator.Struct(r); err != nil {
+		return unwrapErrors(err)
+	}
+
+	return nil
+}
+
+// PageLimit returns the requested number of messages per page, limits over MaxMessagesLimit are capped.
+func (r Messages) PageLimit() int {
+	if r.Limit == 0 {
+		return DefaultMessagesLimit
+	}
+
+	return min(r.Limit, MaxMessagesLimit)
+}
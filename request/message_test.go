@@ -0,0 +1,81 @@
+package request_test
+
+import (
+	"testing"
+
+	"arvanch/request"
+)
+
+func TestMessages_Validate(t *testing.T) {
+	reqValidator, err := request.NewValidator()
+	if err != nil {
+		t.Error(err)
+	}
+
+	tests := []struct {
+		name          string
+		req           request.Messages
+		wantErr       bool
+		expectedLimit int
+	}{
+		{
+			name:          "Successful with defaults",
+			req:           request.Messages{},
+			expectedLimit: request.DefaultMessagesLimit,
+		},
+		{
+			name: "Successful with filters",
+			req: request.Messages{
+				Limit:     50,
+				Status:    "accepted",
+				Recipient: "09121234567",
+				Locale:    "fa",
+				Sort:      "asc",
+			},
+			expectedLimit: 50,
+		},
+		{
+			name:          "Successful with final status",
+			req:           request.Messages{Status: "delivered"},
+			expectedLimit: request.DefaultMessagesLimit,
+		},
+		{
+			name:    "failed with unknown status",
+			req:     request.Messages{Status: "sent"},
+			wantErr: true,
+		},
+		{
+			name:          "Successful with limit over the cap",
+			req:           request.Messages{Limit: 500},
+			expectedLimit: request.MaxMessagesLimit,
+		},
+		{
+			name:    "failed with negative limit",
+			req:     request.Messages{Limit: -1},
+			wantErr: true,
+		},
+		{
+			name:    "failed with invalid sort",
+			req:     request.Messages{Sort: "newest"},
+			wantErr: true,
+		},
+		{
+			name:    "failed with invalid locale",
+			req:     request.Messages{Locale: "de"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.req.Validate(reqValidator)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if !tt.wantErr && tt.req.PageLimit() != tt.expectedLimit {
+				t.Errorf("PageLimit() = %d, expected %d", tt.req.PageLimit(), tt.expectedLimit)
+			}
+		})
+	}
+}
@@ -0,0 +1,22 @@
+package request
+
+import (
+	"github.com/go-playground/validator/v10"
+)
+
+// MessageSearch is the query of searching the message history of all users.
+// Payload is matched as a substring, so it should be long enough to use the trigram index.
+type MessageSearch struct {
+	Messages
+	UserID    string `json:"user_id,omitempty"    query:"user_id"    validate:"omitempty,uuid"`
+	AccountID string `json:"account_id,omitempty" query:"account_id" validate:"omitempty,uuid"`
+	Payload   string `json:"payload,omitempty"    query:"payload"    validate:"omitempty,min=3,max=100"`
+}
+
+func (r MessageSearch) Validate(reqValidator *validator.Validate) error {
+	if err := reqValidator.Struct(r); err != nil {
+		return unwrapErrors(err)
+	}
+
+	return nil
+}
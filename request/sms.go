@@ -1,26 +1,45 @@
 package request
 
 import (
+	"errors"
+	"fmt"
+
 	"arvanch/i18n"
 	"arvanch/pkg/locale"
-	"fmt"
 
 	"github.com/go-playground/validator/v10"
 )
 
+var (
+	// ErrInvalidRecipient indicates that the recipient matches none of the regions.
+	ErrInvalidRecipient = errors.New("recipient format is not valid")
+	// ErrRegionNotAllowed indicates that the recipient's region is not in the region white list.
+	ErrRegionNotAllowed = errors.New("recipient region is not allowed")
+)
+
 type SMS struct {
 	PhoneNumber string        `json:"phone_number"   validate:"required,phone_number,max=100"`
 	Payload     string        `json:"payload"        validate:"required,payload,max=100"`
 	Locale      locale.Locale `json:"locale"         validate:"omitempty,locale"`
+	SenderID    string        `json:"sender_id"      validate:"omitempty,max=20"`
+	// ClientReference and Metadata are stored with the message for the client's own bookkeeping.
+	ClientReference string            `json:"client_reference" validate:"omitempty,max=100"`
+	Metadata        map[string]string `json:"metadata"         validate:"omitempty,max=20,dive,keys,max=40,endkeys,max=200"`
 }
 
-func (r SMS) Validate(reqValidator *validator.Validate) error {
+// Validate validates the request and checks the recipient belongs to one of the white listed regions.
+// Messages of failed validations are in the request's locale.
+func (r SMS) Validate(reqValidator *validator.Validate, regionWhiteList []string) error {
 	if err := reqValidator.Struct(r); err != nil {
-		return unwrapErrors(err)
+		return withLocale(unwrapErrors(err), r.Locale)
 	}
 
-	if !i18n.MatchRegionRegexp([]string{"arvan", "turkey"}, r.PhoneNumber) {
-		return fmt.Errorf("recipient format is not valid [recipient: %s]", r.PhoneNumber)
+	if !i18n.MatchRegionRegexp(regionWhiteList, r.PhoneNumber) {
+		if i18n.MatchRegionRegexp(i18n.RegionNames(), r.PhoneNumber) {
+			return fmt.Errorf("%w [recipient: %s]", ErrRegionNotAllowed, r.PhoneNumber)
+		}
+
+		return fmt.Errorf("%w [recipient: %s]", ErrInvalidRecipient, r.PhoneNumber)
 	}
 
 	return nil
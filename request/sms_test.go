@@ -1,6 +1,8 @@
 package request_test
 
 import (
+	"errors"
+	"strings"
 	"testing"
 
 	"arvanch/pkg/locale"
@@ -19,6 +21,7 @@ func TestSMS_Validate(t *testing.T) {
 		req             request.SMS
 		regionWhiteList []string
 		wantErr         bool
+		expectedErr     error
 	}{
 		{
 			name:            "Successful with phone number",
@@ -86,15 +89,61 @@ func TestSMS_Validate(t *testing.T) {
 			},
 			wantErr: true,
 		},
+		{
+			name:            "Successful with reference and metadata",
+			regionWhiteList: []string{"arvan"},
+			req: request.SMS{
+				PhoneNumber:     "09121234567",
+				Payload:         "Hi",
+				SenderID:        "arvan",
+				ClientReference: "order-1",
+				Metadata:        map[string]string{"campaign": "welcome"},
+			},
+		},
+		{
+			name:            "failed with too long metadata value",
+			regionWhiteList: []string{"arvan"},
+			req: request.SMS{
+				PhoneNumber: "09121234567",
+				Payload:     "Hi",
+				Metadata:    map[string]string{"campaign": strings.Repeat("a", 201)},
+			},
+			wantErr: true,
+		},
+		{
+			name:            "Iranian number with turkey white list",
+			regionWhiteList: []string{"turkey"},
+			req: request.SMS{
+				PhoneNumber: "09121234567",
+				Payload:     "Hi",
+			},
+			wantErr:     true,
+			expectedErr: request.ErrRegionNotAllowed,
+		},
+		{
+			name:            "Invalid number with both regions white listed",
+			regionWhiteList: []string{"arvan", "turkey"},
+			req: request.SMS{
+				PhoneNumber: "0912123456",
+				Payload:     "Hi",
+			},
+			wantErr:     true,
+			expectedErr: request.ErrInvalidRecipient,
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			t.Parallel()
 
-			if err := tt.req.Validate(reqValidator); (err != nil) != tt.wantErr {
+			err := tt.req.Validate(reqValidator, tt.regionWhiteList)
+			if (err != nil) != tt.wantErr {
 				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
 			}
+
+			if tt.expectedErr != nil && !errors.Is(err, tt.expectedErr) {
+				t.Errorf("Validate() error = %v, expectedErr %v", err, tt.expectedErr)
+			}
 		})
 	}
 }
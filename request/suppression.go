@@ -0,0 +1,31 @@
+package request
+
+import (
+	"github.com/go-playground/validator/v10"
+)
+
+type Suppression struct {
+	PhoneNumber string `json:"phone_number" query:"phone_number" validate:"required,phone_number,max=100"`
+}
+
+func (r Suppression) Validate(reqValidator *validator.Validate) error {
+	if err := reqValidator.Struct(r); err != nil {
+		return unwrapErrors(err)
+	}
+
+	return nil
+}
+
+// Inbound is an SMS received from a recipient, reported by the provider.
+type Inbound struct {
+	Sender  string `json:"sender"         validate:"required,max=100"`
+	Payload string `json:"payload"        validate:"required"`
+}
+
+func (r Inbound) Validate(reqValidator *validator.Validate) error {
+	if err := reqValidator.Struct(r); err != nil {
+		return unwrapErrors(err)
+	}
+
+	return nil
+}
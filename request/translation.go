@@ -0,0 +1,144 @@
+package request
+
+import (
+	"fmt"
+	"reflect"
+
+	"arvanch/pkg/locale"
+
+	"github.com/go-playground/locales"
+	"github.com/go-playground/locales/ar"
+	"github.com/go-playground/locales/ckb"
+	"github.com/go-playground/locales/en"
+	"github.com/go-playground/locales/fa"
+	ut "github.com/go-playground/universal-translator"
+)
+
+// invalidKey is the message of tags without a translation.
+const invalidKey = "invalid"
+
+// messages are the validation messages of each locale by tag, {0} is the field and {1} is the tag's parameter.
+// min and max have a message for each kind of field, e.g. max_string for the length of strings.
+// nolint:gochecknoglobals,lll
+var messages = map[locale.Locale]map[string]string{
+	locale.EN: {
+		invalidKey:     "{0} is not valid",
+		"required":     "{0} is required",
+		"uuid":         "{0} must be a valid UUID",
+		"oneof":        "{0} must be one of [{1}]",
+		"min":          "{0} must be {1} or greater",
+		"max":          "{0} must be {1} or less",
+		"min_string":   "{0} must be at least {1} characters",
+		"max_string":   "{0} must be at most {1} characters",
+		"max_items":    "{0} must contain at most {1} items",
+		"phone_number": "{0} must be a valid phone number",
+		"payload":      fmt.Sprintf("{0} must be between %d and %d characters", minPayloadCharacterLen, maxPayloadCharacterLen),
+		"account":      "{0} must contain only letters, '_' and '.'",
+		"locale":       "{0} must be a supported locale",
+	},
+	locale.FA: {
+		invalidKey:     "{0} معتبر نیست",
+		"required":     "فیلد {0} اجباری است",
+		"uuid":         "{0} باید یک UUID معتبر باشد",
+		"oneof":        "{0} باید یکی از مقادیر [{1}] باشد",
+		"min":          "{0} باید {1} یا بیشتر باشد",
+		"max":          "{0} باید {1} یا کمتر باشد",
+		"min_string":   "{0} باید حداقل {1} کاراکتر باشد",
+		"max_string":   "{0} باید حداکثر {1} کاراکتر باشد",
+		"max_items":    "{0} باید حداکثر {1} مورد داشته باشد",
+		"phone_number": "{0} باید یک شماره تلفن معتبر باشد",
+		"payload":      fmt.Sprintf("{0} باید بین %d تا %d کاراکتر باشد", minPayloadCharacterLen, maxPayloadCharacterLen),
+		"account":      "{0} فقط می‌تواند شامل حروف، '_' و '.' باشد",
+		"locale":       "{0} باید یکی از زبان‌های پشتیبانی‌شده باشد",
+	},
+	locale.AR: {
+		invalidKey:     "{0} غير صالح",
+		"required":     "الحقل {0} مطلوب",
+		"uuid":         "يجب أن يكون {0} معرف UUID صالح",
+		"oneof":        "يجب أن يكون {0} أحد القيم [{1}]",
+		"min":          "يجب أن يكون {0} {1} أو أكثر",
+		"max":          "يجب أن يكون {0} {1} أو أقل",
+		"min_string":   "يجب أن يكون طول {0} {1} حرفًا على الأقل",
+		"max_string":   "يجب أن يكون طول {0} {1} حرفًا كحد أقصى",
+		"max_items":    "يجب أن يحتوي {0} على {1} عنصرًا كحد أقصى",
+		"phone_number": "يجب أن يكون {0} رقم هاتف صالح",
+		"payload":      fmt.Sprintf("يجب أن يكون طول {0} بين %d و%d حرفًا", minPayloadCharacterLen, maxPayloadCharacterLen),
+		"account":      "يجب أن يحتوي {0} على أحرف و'_' و'.' فقط",
+		"locale":       "يجب أن يكون {0} لغة مدعومة",
+	},
+	locale.KU: {
+		invalidKey:     "{0} دروست نییە",
+		"required":     "خانەی {0} پێویستە",
+		"uuid":         "{0} دەبێت UUIDێکی دروست بێت",
+		"oneof":        "{0} دەبێت یەکێک بێت لە [{1}]",
+		"min":          "{0} دەبێت {1} یان زیاتر بێت",
+		"max":          "{0} دەبێت {1} یان کەمتر بێت",
+		"min_string":   "{0} دەبێت لانیکەم {1} پیت بێت",
+		"max_string":   "{0} دەبێت لە {1} پیت زیاتر نەبێت",
+		"max_items":    "{0} دەبێت لە {1} دانە زیاتر نەبێت",
+		"phone_number": "{0} دەبێت ژمارەی تەلەفۆنێکی دروست بێت",
+		"payload":      fmt.Sprintf("{0} دەبێت لە نێوان %d و %d پیت بێت", minPayloadCharacterLen, maxPayloadCharacterLen),
+		"account":      "{0} تەنها دەتوانێت پیت و '_' و '.' لەخۆبگرێت",
+		"locale":       "{0} دەبێت زمانێکی پشتگیریکراو بێت",
+	},
+}
+
+// translators are the translators of validation messages by locale, Kurdish messages are in Sorani.
+// nolint:gochecknoglobals
+var translators = mustTranslators()
+
+func mustTranslators() map[locale.Locale]ut.Translator {
+	supported := map[locale.Locale]locales.Translator{
+		locale.EN: en.New(),
+		locale.FA: fa.New(),
+		locale.AR: ar.New(),
+		locale.KU: ckb.New(),
+	}
+
+	uni := ut.New(supported[locale.EN])
+	result := make(map[locale.Locale]ut.Translator, len(supported))
+
+	for l, lt := range supported {
+		if err := uni.AddTranslator(lt, true); err != nil {
+			panic(err)
+		}
+
+		trans, _ := uni.GetTranslator(lt.Locale())
+
+		for key, text := range messages[l] {
+			if err := trans.Add(key, text, false); err != nil {
+				panic(err)
+			}
+		}
+
+		result[l] = trans
+	}
+
+	return result
+}
+
+// translate returns the message of a failed validation in the locale, in English when the locale is not supported.
+func translate(l locale.Locale, field, tag, param string, kind reflect.Kind) string {
+	trans, ok := translators[l]
+	if !ok {
+		trans = translators[locale.EN]
+	}
+
+	key := tag
+
+	if tag == "min" || tag == "max" {
+		switch kind { // nolint:exhaustive
+		case reflect.String:
+			key = tag + "_string"
+		case reflect.Map, reflect.Slice, reflect.Array:
+			key = tag + "_items"
+		}
+	}
+
+	msg, err := trans.T(key, field, param)
+	if err != nil {
+		msg, _ = trans.T(invalidKey, field)
+	}
+
+	return msg
+}
@@ -3,7 +3,9 @@ package request
 import (
 	"errors"
 	"fmt"
+	"reflect"
 	"regexp"
+	"strings"
 	"unicode/utf8"
 
 	"arvanch/pkg/locale"
@@ -11,9 +13,12 @@ import (
 	"github.com/go-playground/validator/v10"
 )
 
+// ErrInvalidParameters indicates that the request's parameters failed validation.
+var ErrInvalidParameters = errors.New("invalid parameters")
+
 var (
 	accountRegex   = regexp.MustCompile("^[a-zA-Z_.]+$")
-	recipientRegex = regexp.MustCompile("^[0-9]+$")
+	recipientRegex = regexp.MustCompile(`^\+?[0-9]+$`)
 )
 
 const (
@@ -27,6 +32,9 @@ const (
 func NewValidator() (*validator.Validate, error) {
 	reqValidator := validator.New()
 
+	// fields are reported by their json name, the one clients send.
+	reqValidator.RegisterTagNameFunc(jsonName)
+
 	// and represents `account` validator.
 	validations := map[string]func(fl validator.FieldLevel) bool{
 		"phone_number": phoneNumberValidation,
@@ -44,6 +52,20 @@ func NewValidator() (*validator.Validate, error) {
 	return reqValidator, nil
 }
 
+// jsonName returns the json name of a struct field, or its name when it has none.
+func jsonName(field reflect.StructField) string {
+	name := strings.Split(field.Tag.Get("json"), ",")[0]
+
+	switch name {
+	case "-":
+		return ""
+	case "":
+		return field.Name
+	default:
+		return name
+	}
+}
+
 // localeValid checks the validity of the locale and represents `locale` validator.
 func localeValid(fl validator.FieldLevel) bool {
 	return locale.Validate(locale.Locale(fl.Field().String())) == nil
@@ -54,7 +76,7 @@ func accountValidation(fl validator.FieldLevel) bool {
 	return accountRegex.MatchString(fl.Field().String())
 }
 
-// accountValidation checks the validity of the account and represents `account` validator.
+// phoneNumberValidation checks the validity of the phone number and represents `phone_number` validator.
 func phoneNumberValidation(fl validator.FieldLevel) bool {
 	return recipientRegex.MatchString(fl.Field().String())
 }
@@ -68,6 +90,65 @@ func payloadValidation(fl validator.FieldLevel) bool {
 		len(payload) >= minPayloadByteLen && len(payload) <= maxPayloadByteLen
 }
 
+// FieldError is a failed validation of a request's field.
+type FieldError struct {
+	Field   string `json:"field"`
+	Tag     string `json:"tag"`
+	Param   string `json:"param,omitempty"`
+	Message string `json:"message"`
+}
+
+// ValidationError is returned when the request's parameters failed validation, it wraps ErrInvalidParameters.
+// locale is the locale the request asked for, if any.
+type ValidationError struct {
+	errs   validator.ValidationErrors
+	locale locale.Locale
+}
+
+func (e *ValidationError) Error() string {
+	fields := make([]string, len(e.errs))
+	for i := range e.errs {
+		fields[i] = e.errs[i].Field()
+	}
+
+	return fmt.Sprintf("%s: %v", ErrInvalidParameters.Error(), fields)
+}
+
+func (e *ValidationError) Unwrap() error {
+	return ErrInvalidParameters
+}
+
+// Locale returns the supported locale of the request, or an empty one when the request did not ask for one.
+func (e *ValidationError) Locale() locale.Locale {
+	return e.locale
+}
+
+// Fields returns the failed validations with their messages in the locale.
+func (e *ValidationError) Fields(l locale.Locale) []FieldError {
+	fields := make([]FieldError, len(e.errs))
+
+	for i, fe := range e.errs {
+		fields[i] = FieldError{
+			Field:   fe.Field(),
+			Tag:     fe.Tag(),
+			Param:   fe.Param(),
+			Message: translate(l, fe.Field(), fe.Tag(), fe.Param(), fe.Kind()),
+		}
+	}
+
+	return fields
+}
+
+// withLocale sets the locale of a validation error to the request's locale when it is supported.
+func withLocale(err error, l locale.Locale) error {
+	var validationErr *ValidationError
+	if errors.As(err, &validationErr) && locale.Validate(l) == nil {
+		validationErr.locale = l
+	}
+
+	return err
+}
+
 // nolint:err113
 func unwrapErrors(err error) error {
 	if err == nil {
@@ -79,10 +160,5 @@ func unwrapErrors(err error) error {
 		return errors.New("unknown error")
 	}
 
-	errorsStr := make([]string, len(validationErrors))
-	for i := range validationErrors {
-		errorsStr[i] = validationErrors[i].Field()
-	}
-
-	return fmt.Errorf("invalid parameters: %v", errorsStr)
+	return &ValidationError{errs: validationErrors}
 }
@@ -0,0 +1,87 @@
+package request_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"arvanch/pkg/locale"
+	"arvanch/request"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidationErrorFields(t *testing.T) {
+	t.Parallel()
+
+	reqValidator, err := request.NewValidator()
+	require.NoError(t, err)
+
+	err = request.SMS{
+		PhoneNumber: "0912abc",
+		Payload:     strings.Repeat("a", 101),
+		Locale:      locale.EN,
+	}.Validate(reqValidator, []string{"arvan"})
+	require.ErrorIs(t, err, request.ErrInvalidParameters)
+
+	var validationErr *request.ValidationError
+	require.True(t, errors.As(err, &validationErr))
+	require.Equal(t, "invalid parameters: [phone_number payload]", err.Error())
+
+	cases := []struct {
+		locale   locale.Locale
+		expected []string
+	}{
+		{
+			locale:   locale.EN,
+			expected: []string{"phone_number must be a valid phone number", "payload must be between 1 and 100 characters"},
+		},
+		{
+			locale:   locale.FA,
+			expected: []string{"phone_number باید یک شماره تلفن معتبر باشد", "payload باید بین 1 تا 100 کاراکتر باشد"},
+		},
+		{
+			locale:   locale.AR,
+			expected: []string{"يجب أن يكون phone_number رقم هاتف صالح", "يجب أن يكون طول payload بين 1 و100 حرفًا"},
+		},
+		{
+			locale:   locale.KU,
+			expected: []string{"phone_number دەبێت ژمارەی تەلەفۆنێکی دروست بێت", "payload دەبێت لە نێوان 1 و 100 پیت بێت"},
+		},
+		{
+			locale:   locale.Default,
+			expected: []string{"phone_number must be a valid phone number", "payload must be between 1 and 100 characters"},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.locale.String(), func(t *testing.T) {
+			t.Parallel()
+
+			fields := validationErr.Fields(tc.locale)
+			require.Len(t, fields, len(tc.expected))
+
+			for i := range fields {
+				require.Equal(t, tc.expected[i], fields[i].Message)
+			}
+		})
+	}
+}
+
+func TestValidationErrorParams(t *testing.T) {
+	t.Parallel()
+
+	reqValidator, err := request.NewValidator()
+	require.NoError(t, err)
+
+	err = request.Messages{Limit: -1, Sort: "up"}.Validate(reqValidator)
+	require.ErrorIs(t, err, request.ErrInvalidParameters)
+
+	var validationErr *request.ValidationError
+	require.True(t, errors.As(err, &validationErr))
+
+	require.Equal(t, []request.FieldError{
+		{Field: "limit", Tag: "min", Param: "1", Message: "limit must be 1 or greater"},
+		{Field: "sort", Tag: "oneof", Param: "asc desc", Message: "sort must be one of [asc desc]"},
+	}, validationErr.Fields(locale.EN))
+}